@@ -0,0 +1,84 @@
+// breachfilter собирает фильтр Блума из файла Pwned Passwords (SHA-1, ordered by hash или by count)
+// для password_policy.breached_file.
+//
+//	go run ./cmd/breachfilter -in pwned-passwords-sha1.txt -out breached.bloom -fp 0.001 -min-count 10
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/lib/breach"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "", "HIBP SHA-1 file, one HASH:COUNT per line")
+	out := flag.String("out", "breached.bloom", "output bloom filter file")
+	fpRate := flag.Float64("fp", 0.001, "false positive rate")
+	minCount := flag.Int64("min-count", 1, "skip hashes seen fewer times than this")
+	flag.Parse()
+
+	if *in == "" || *fpRate <= 0 || *fpRate >= 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// первый проход считает хеши, чтобы рассчитать размер фильтра
+	n, err := scan(*in, *minCount, func([20]byte) {})
+	if err != nil {
+		fail(err)
+	}
+
+	bloom := breach.NewBloom(n, *fpRate)
+	if _, err := scan(*in, *minCount, bloom.Add); err != nil {
+		fail(err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		fail(err)
+	}
+	size, err := bloom.WriteTo(f)
+	if err != nil {
+		f.Close()
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d hashes, %d bytes written to %s\n", n, size, *out)
+}
+
+func scan(path string, minCount int64, add func([20]byte)) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n uint64
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		hash, count, err := breach.ParseLine(scanner.Bytes())
+		if err != nil {
+			return 0, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if count < minCount {
+			continue
+		}
+		add(hash)
+		n++
+	}
+
+	return n, scanner.Err()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "breachfilter:", err)
+	os.Exit(1)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/AronditFire/User-Service/internal/app"
 	"github.com/AronditFire/User-Service/internal/config"
 	"github.com/golang-migrate/migrate/v4"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -22,11 +25,21 @@ func main() {
 
 	MigratePostgres(cfg.PostgresDSN, cfg.MigrationURL, log)
 
-	log.Info("Starting User Service", slog.Any("config", cfg)) // TODO: убрать конфиг
+	log.Info("Starting User Service", slog.String("env", cfg.Env), slog.Int("grpc_port", cfg.GRPC.Port)) // секреты не логируем
 
-	application := app.New(log, cfg.GRPC.Port, cfg.PostgresDSN, cfg.AccessTTL, cfg.RefreshTTL, cfg.JWTSecret)
+	application := app.New(log, cfg)
+
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	go application.Denylist.Run(syncCtx)
 
 	go application.GRPCServer.MustRun()
+	go func() {
+		log.Info("HTTP server is running", slog.String("addr", application.GINHTTPGateway.Addr))
+		if err := application.GINHTTPGateway.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
 
 	// TODO: shutdown
 	stop := make(chan os.Signal, 1)
@@ -35,6 +48,12 @@ func main() {
 
 	application.GRPCServer.Stop()
 
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.Timeout)
+	defer cancel()
+	if err := application.GINHTTPGateway.Shutdown(ctx); err != nil {
+		log.Error("failed to stop HTTP server", slog.Any("error", err))
+	}
+
 	log.Info("Gracefully stopped")
 }
 
@@ -0,0 +1,134 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/admin.proto
+
+package accountv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AdminUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminUserRequest) Reset() {
+	*x = AdminUserRequest{}
+	mi := &file_account_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminUserRequest) ProtoMessage() {}
+
+func (x *AdminUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminUserRequest.ProtoReflect.Descriptor instead.
+func (*AdminUserRequest) Descriptor() ([]byte, []int) {
+	return file_account_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AdminUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+var File_account_admin_proto protoreflect.FileDescriptor
+
+const file_account_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x13account/admin.proto\x12\fuser_profile\x1a\x1bgoogle/protobuf/empty.proto\"+\n" +
+	"\x10AdminUserRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId2\xe3\x01\n" +
+	"\fAdminService\x12C\n" +
+	"\tBlockUser\x12\x1e.user_profile.AdminUserRequest\x1a\x16.google.protobuf.Empty\x12E\n" +
+	"\vUnblockUser\x12\x1e.user_profile.AdminUserRequest\x1a\x16.google.protobuf.Empty\x12G\n" +
+	"\rUnlockAccount\x12\x1e.user_profile.AdminUserRequest\x1a\x16.google.protobuf.EmptyB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_admin_proto_rawDescOnce sync.Once
+	file_account_admin_proto_rawDescData []byte
+)
+
+func file_account_admin_proto_rawDescGZIP() []byte {
+	file_account_admin_proto_rawDescOnce.Do(func() {
+		file_account_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_admin_proto_rawDesc), len(file_account_admin_proto_rawDesc)))
+	})
+	return file_account_admin_proto_rawDescData
+}
+
+var file_account_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_account_admin_proto_goTypes = []any{
+	(*AdminUserRequest)(nil), // 0: user_profile.AdminUserRequest
+	(*emptypb.Empty)(nil),    // 1: google.protobuf.Empty
+}
+var file_account_admin_proto_depIdxs = []int32{
+	0, // 0: user_profile.AdminService.BlockUser:input_type -> user_profile.AdminUserRequest
+	0, // 1: user_profile.AdminService.UnblockUser:input_type -> user_profile.AdminUserRequest
+	0, // 2: user_profile.AdminService.UnlockAccount:input_type -> user_profile.AdminUserRequest
+	1, // 3: user_profile.AdminService.BlockUser:output_type -> google.protobuf.Empty
+	1, // 4: user_profile.AdminService.UnblockUser:output_type -> google.protobuf.Empty
+	1, // 5: user_profile.AdminService.UnlockAccount:output_type -> google.protobuf.Empty
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_account_admin_proto_init() }
+func file_account_admin_proto_init() {
+	if File_account_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_admin_proto_rawDesc), len(file_account_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_admin_proto_goTypes,
+		DependencyIndexes: file_account_admin_proto_depIdxs,
+		MessageInfos:      file_account_admin_proto_msgTypes,
+	}.Build()
+	File_account_admin_proto = out.File
+	file_account_admin_proto_goTypes = nil
+	file_account_admin_proto_depIdxs = nil
+}
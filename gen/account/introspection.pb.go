@@ -0,0 +1,298 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/introspection.proto
+
+package accountv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Проверка токена по RFC 7662
+type IntrospectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	TokenTypeHint string                 `protobuf:"bytes,2,opt,name=token_type_hint,json=tokenTypeHint,proto3" json:"token_type_hint,omitempty"` // поддерживается только access_token
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntrospectRequest) Reset() {
+	*x = IntrospectRequest{}
+	mi := &file_account_introspection_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntrospectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntrospectRequest) ProtoMessage() {}
+
+func (x *IntrospectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_introspection_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntrospectRequest.ProtoReflect.Descriptor instead.
+func (*IntrospectRequest) Descriptor() ([]byte, []int) {
+	return file_account_introspection_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *IntrospectRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *IntrospectRequest) GetTokenTypeHint() string {
+	if x != nil {
+		return x.TokenTypeHint
+	}
+	return ""
+}
+
+type IntrospectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Active        bool                   `protobuf:"varint,1,opt,name=active,proto3" json:"active,omitempty"` // остальные поля заполнены только для активного токена
+	Sub           string                 `protobuf:"bytes,2,opt,name=sub,proto3" json:"sub,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Roles         []string               `protobuf:"bytes,4,rep,name=roles,proto3" json:"roles,omitempty"`
+	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Jti           string                 `protobuf:"bytes,6,opt,name=jti,proto3" json:"jti,omitempty"`
+	Iss           string                 `protobuf:"bytes,7,opt,name=iss,proto3" json:"iss,omitempty"`
+	Aud           []string               `protobuf:"bytes,8,rep,name=aud,proto3" json:"aud,omitempty"`
+	Iat           *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=iat,proto3" json:"iat,omitempty"`
+	Exp           *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=exp,proto3" json:"exp,omitempty"`
+	TokenType     string                 `protobuf:"bytes,11,opt,name=token_type,json=tokenType,proto3" json:"token_type,omitempty"`
+	Scope         string                 `protobuf:"bytes,12,opt,name=scope,proto3" json:"scope,omitempty"` // через пробел, только у токенов OAuth клиентов
+	ClientId      string                 `protobuf:"bytes,13,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntrospectResponse) Reset() {
+	*x = IntrospectResponse{}
+	mi := &file_account_introspection_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntrospectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntrospectResponse) ProtoMessage() {}
+
+func (x *IntrospectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_introspection_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntrospectResponse.ProtoReflect.Descriptor instead.
+func (*IntrospectResponse) Descriptor() ([]byte, []int) {
+	return file_account_introspection_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *IntrospectResponse) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *IntrospectResponse) GetSub() string {
+	if x != nil {
+		return x.Sub
+	}
+	return ""
+}
+
+func (x *IntrospectResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *IntrospectResponse) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *IntrospectResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *IntrospectResponse) GetJti() string {
+	if x != nil {
+		return x.Jti
+	}
+	return ""
+}
+
+func (x *IntrospectResponse) GetIss() string {
+	if x != nil {
+		return x.Iss
+	}
+	return ""
+}
+
+func (x *IntrospectResponse) GetAud() []string {
+	if x != nil {
+		return x.Aud
+	}
+	return nil
+}
+
+func (x *IntrospectResponse) GetIat() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Iat
+	}
+	return nil
+}
+
+func (x *IntrospectResponse) GetExp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Exp
+	}
+	return nil
+}
+
+func (x *IntrospectResponse) GetTokenType() string {
+	if x != nil {
+		return x.TokenType
+	}
+	return ""
+}
+
+func (x *IntrospectResponse) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *IntrospectResponse) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+var File_account_introspection_proto protoreflect.FileDescriptor
+
+const file_account_introspection_proto_rawDesc = "" +
+	"\n" +
+	"\x1baccount/introspection.proto\x12\fuser_profile\x1a\x1fgoogle/protobuf/timestamp.proto\"Q\n" +
+	"\x11IntrospectRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12&\n" +
+	"\x0ftoken_type_hint\x18\x02 \x01(\tR\rtokenTypeHint\"\xf0\x02\n" +
+	"\x12IntrospectResponse\x12\x16\n" +
+	"\x06active\x18\x01 \x01(\bR\x06active\x12\x10\n" +
+	"\x03sub\x18\x02 \x01(\tR\x03sub\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05roles\x18\x04 \x03(\tR\x05roles\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03jti\x18\x06 \x01(\tR\x03jti\x12\x10\n" +
+	"\x03iss\x18\a \x01(\tR\x03iss\x12\x10\n" +
+	"\x03aud\x18\b \x03(\tR\x03aud\x12,\n" +
+	"\x03iat\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x03iat\x12,\n" +
+	"\x03exp\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x03exp\x12\x1d\n" +
+	"\n" +
+	"token_type\x18\v \x01(\tR\ttokenType\x12\x14\n" +
+	"\x05scope\x18\f \x01(\tR\x05scope\x12\x1b\n" +
+	"\tclient_id\x18\r \x01(\tR\bclientId2l\n" +
+	"\x14IntrospectionService\x12T\n" +
+	"\x0fIntrospectToken\x12\x1f.user_profile.IntrospectRequest\x1a .user_profile.IntrospectResponseB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_introspection_proto_rawDescOnce sync.Once
+	file_account_introspection_proto_rawDescData []byte
+)
+
+func file_account_introspection_proto_rawDescGZIP() []byte {
+	file_account_introspection_proto_rawDescOnce.Do(func() {
+		file_account_introspection_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_introspection_proto_rawDesc), len(file_account_introspection_proto_rawDesc)))
+	})
+	return file_account_introspection_proto_rawDescData
+}
+
+var file_account_introspection_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_account_introspection_proto_goTypes = []any{
+	(*IntrospectRequest)(nil),     // 0: user_profile.IntrospectRequest
+	(*IntrospectResponse)(nil),    // 1: user_profile.IntrospectResponse
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_account_introspection_proto_depIdxs = []int32{
+	2, // 0: user_profile.IntrospectResponse.iat:type_name -> google.protobuf.Timestamp
+	2, // 1: user_profile.IntrospectResponse.exp:type_name -> google.protobuf.Timestamp
+	0, // 2: user_profile.IntrospectionService.IntrospectToken:input_type -> user_profile.IntrospectRequest
+	1, // 3: user_profile.IntrospectionService.IntrospectToken:output_type -> user_profile.IntrospectResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_account_introspection_proto_init() }
+func file_account_introspection_proto_init() {
+	if File_account_introspection_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_introspection_proto_rawDesc), len(file_account_introspection_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_introspection_proto_goTypes,
+		DependencyIndexes: file_account_introspection_proto_depIdxs,
+		MessageInfos:      file_account_introspection_proto_msgTypes,
+	}.Build()
+	File_account_introspection_proto = out.File
+	file_account_introspection_proto_goTypes = nil
+	file_account_introspection_proto_depIdxs = nil
+}
@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.31.1
+// source: account/introspection.proto
+
+package accountv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IntrospectionService_IntrospectToken_FullMethodName = "/user_profile.IntrospectionService/IntrospectToken"
+)
+
+// IntrospectionServiceClient is the client API for IntrospectionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Для сервисов, которые не проверяют токены сами
+type IntrospectionServiceClient interface {
+	IntrospectToken(ctx context.Context, in *IntrospectRequest, opts ...grpc.CallOption) (*IntrospectResponse, error)
+}
+
+type introspectionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIntrospectionServiceClient(cc grpc.ClientConnInterface) IntrospectionServiceClient {
+	return &introspectionServiceClient{cc}
+}
+
+func (c *introspectionServiceClient) IntrospectToken(ctx context.Context, in *IntrospectRequest, opts ...grpc.CallOption) (*IntrospectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntrospectResponse)
+	err := c.cc.Invoke(ctx, IntrospectionService_IntrospectToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IntrospectionServiceServer is the server API for IntrospectionService service.
+// All implementations must embed UnimplementedIntrospectionServiceServer
+// for forward compatibility.
+//
+// Для сервисов, которые не проверяют токены сами
+type IntrospectionServiceServer interface {
+	IntrospectToken(context.Context, *IntrospectRequest) (*IntrospectResponse, error)
+	mustEmbedUnimplementedIntrospectionServiceServer()
+}
+
+// UnimplementedIntrospectionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIntrospectionServiceServer struct{}
+
+func (UnimplementedIntrospectionServiceServer) IntrospectToken(context.Context, *IntrospectRequest) (*IntrospectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IntrospectToken not implemented")
+}
+func (UnimplementedIntrospectionServiceServer) mustEmbedUnimplementedIntrospectionServiceServer() {}
+func (UnimplementedIntrospectionServiceServer) testEmbeddedByValue()                              {}
+
+// UnsafeIntrospectionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IntrospectionServiceServer will
+// result in compilation errors.
+type UnsafeIntrospectionServiceServer interface {
+	mustEmbedUnimplementedIntrospectionServiceServer()
+}
+
+func RegisterIntrospectionServiceServer(s grpc.ServiceRegistrar, srv IntrospectionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedIntrospectionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IntrospectionService_ServiceDesc, srv)
+}
+
+func _IntrospectionService_IntrospectToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntrospectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntrospectionServiceServer).IntrospectToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IntrospectionService_IntrospectToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntrospectionServiceServer).IntrospectToken(ctx, req.(*IntrospectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IntrospectionService_ServiceDesc is the grpc.ServiceDesc for IntrospectionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IntrospectionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user_profile.IntrospectionService",
+	HandlerType: (*IntrospectionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "IntrospectToken",
+			Handler:    _IntrospectionService_IntrospectToken_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "account/introspection.proto",
+}
@@ -0,0 +1,250 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/keys.proto
+
+package accountv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Публичный ключ в формате JWK (RFC 7517)
+type JWK struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kid           string                 `protobuf:"bytes,1,opt,name=kid,proto3" json:"kid,omitempty"`
+	Kty           string                 `protobuf:"bytes,2,opt,name=kty,proto3" json:"kty,omitempty"`
+	Alg           string                 `protobuf:"bytes,3,opt,name=alg,proto3" json:"alg,omitempty"`
+	Use           string                 `protobuf:"bytes,4,opt,name=use,proto3" json:"use,omitempty"`
+	N             string                 `protobuf:"bytes,5,opt,name=n,proto3" json:"n,omitempty"`     // RSA
+	E             string                 `protobuf:"bytes,6,opt,name=e,proto3" json:"e,omitempty"`     // RSA
+	Crv           string                 `protobuf:"bytes,7,opt,name=crv,proto3" json:"crv,omitempty"` // EC, OKP
+	X             string                 `protobuf:"bytes,8,opt,name=x,proto3" json:"x,omitempty"`     // EC, OKP
+	Y             string                 `protobuf:"bytes,9,opt,name=y,proto3" json:"y,omitempty"`     // EC
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JWK) Reset() {
+	*x = JWK{}
+	mi := &file_account_keys_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JWK) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JWK) ProtoMessage() {}
+
+func (x *JWK) ProtoReflect() protoreflect.Message {
+	mi := &file_account_keys_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JWK.ProtoReflect.Descriptor instead.
+func (*JWK) Descriptor() ([]byte, []int) {
+	return file_account_keys_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *JWK) GetKid() string {
+	if x != nil {
+		return x.Kid
+	}
+	return ""
+}
+
+func (x *JWK) GetKty() string {
+	if x != nil {
+		return x.Kty
+	}
+	return ""
+}
+
+func (x *JWK) GetAlg() string {
+	if x != nil {
+		return x.Alg
+	}
+	return ""
+}
+
+func (x *JWK) GetUse() string {
+	if x != nil {
+		return x.Use
+	}
+	return ""
+}
+
+func (x *JWK) GetN() string {
+	if x != nil {
+		return x.N
+	}
+	return ""
+}
+
+func (x *JWK) GetE() string {
+	if x != nil {
+		return x.E
+	}
+	return ""
+}
+
+func (x *JWK) GetCrv() string {
+	if x != nil {
+		return x.Crv
+	}
+	return ""
+}
+
+func (x *JWK) GetX() string {
+	if x != nil {
+		return x.X
+	}
+	return ""
+}
+
+func (x *JWK) GetY() string {
+	if x != nil {
+		return x.Y
+	}
+	return ""
+}
+
+type JWKSResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*JWK                 `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JWKSResponse) Reset() {
+	*x = JWKSResponse{}
+	mi := &file_account_keys_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JWKSResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JWKSResponse) ProtoMessage() {}
+
+func (x *JWKSResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_keys_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JWKSResponse.ProtoReflect.Descriptor instead.
+func (*JWKSResponse) Descriptor() ([]byte, []int) {
+	return file_account_keys_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JWKSResponse) GetKeys() []*JWK {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+var File_account_keys_proto protoreflect.FileDescriptor
+
+const file_account_keys_proto_rawDesc = "" +
+	"\n" +
+	"\x12account/keys.proto\x12\fuser_profile\x1a\x1bgoogle/protobuf/empty.proto\"\x97\x01\n" +
+	"\x03JWK\x12\x10\n" +
+	"\x03kid\x18\x01 \x01(\tR\x03kid\x12\x10\n" +
+	"\x03kty\x18\x02 \x01(\tR\x03kty\x12\x10\n" +
+	"\x03alg\x18\x03 \x01(\tR\x03alg\x12\x10\n" +
+	"\x03use\x18\x04 \x01(\tR\x03use\x12\f\n" +
+	"\x01n\x18\x05 \x01(\tR\x01n\x12\f\n" +
+	"\x01e\x18\x06 \x01(\tR\x01e\x12\x10\n" +
+	"\x03crv\x18\a \x01(\tR\x03crv\x12\f\n" +
+	"\x01x\x18\b \x01(\tR\x01x\x12\f\n" +
+	"\x01y\x18\t \x01(\tR\x01y\"5\n" +
+	"\fJWKSResponse\x12%\n" +
+	"\x04keys\x18\x01 \x03(\v2\x11.user_profile.JWKR\x04keys2K\n" +
+	"\n" +
+	"KeyService\x12=\n" +
+	"\aGetJWKS\x12\x16.google.protobuf.Empty\x1a\x1a.user_profile.JWKSResponseB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_keys_proto_rawDescOnce sync.Once
+	file_account_keys_proto_rawDescData []byte
+)
+
+func file_account_keys_proto_rawDescGZIP() []byte {
+	file_account_keys_proto_rawDescOnce.Do(func() {
+		file_account_keys_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_keys_proto_rawDesc), len(file_account_keys_proto_rawDesc)))
+	})
+	return file_account_keys_proto_rawDescData
+}
+
+var file_account_keys_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_account_keys_proto_goTypes = []any{
+	(*JWK)(nil),           // 0: user_profile.JWK
+	(*JWKSResponse)(nil),  // 1: user_profile.JWKSResponse
+	(*emptypb.Empty)(nil), // 2: google.protobuf.Empty
+}
+var file_account_keys_proto_depIdxs = []int32{
+	0, // 0: user_profile.JWKSResponse.keys:type_name -> user_profile.JWK
+	2, // 1: user_profile.KeyService.GetJWKS:input_type -> google.protobuf.Empty
+	1, // 2: user_profile.KeyService.GetJWKS:output_type -> user_profile.JWKSResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_account_keys_proto_init() }
+func file_account_keys_proto_init() {
+	if File_account_keys_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_keys_proto_rawDesc), len(file_account_keys_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_keys_proto_goTypes,
+		DependencyIndexes: file_account_keys_proto_depIdxs,
+		MessageInfos:      file_account_keys_proto_msgTypes,
+	}.Build()
+	File_account_keys_proto = out.File
+	file_account_keys_proto_goTypes = nil
+	file_account_keys_proto_depIdxs = nil
+}
@@ -0,0 +1,364 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/mfa.proto
+
+package accountv1
+
+import (
+	user_service "github.com/AronditFire/UService-ProtobufNew/gen/user-service"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Секрет показывается один раз, 2FA включается только после ConfirmTOTP
+type EnrollTOTPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Secret        string                 `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"`                                    // base32, для ручного ввода
+	OtpauthUri    string                 `protobuf:"bytes,2,opt,name=otpauth_uri,json=otpauthUri,proto3" json:"otpauth_uri,omitempty"`          // для QR-кода
+	RecoveryCodes []string               `protobuf:"bytes,3,rep,name=recovery_codes,json=recoveryCodes,proto3" json:"recovery_codes,omitempty"` // одноразовые, действуют после ConfirmTOTP
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollTOTPResponse) Reset() {
+	*x = EnrollTOTPResponse{}
+	mi := &file_account_mfa_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollTOTPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollTOTPResponse) ProtoMessage() {}
+
+func (x *EnrollTOTPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_mfa_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollTOTPResponse.ProtoReflect.Descriptor instead.
+func (*EnrollTOTPResponse) Descriptor() ([]byte, []int) {
+	return file_account_mfa_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EnrollTOTPResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *EnrollTOTPResponse) GetOtpauthUri() string {
+	if x != nil {
+		return x.OtpauthUri
+	}
+	return ""
+}
+
+func (x *EnrollTOTPResponse) GetRecoveryCodes() []string {
+	if x != nil {
+		return x.RecoveryCodes
+	}
+	return nil
+}
+
+// code - код из приложения, там где включена 2FA подходит и recovery code
+type TOTPCodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TOTPCodeRequest) Reset() {
+	*x = TOTPCodeRequest{}
+	mi := &file_account_mfa_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TOTPCodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TOTPCodeRequest) ProtoMessage() {}
+
+func (x *TOTPCodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_mfa_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TOTPCodeRequest.ProtoReflect.Descriptor instead.
+func (*TOTPCodeRequest) Descriptor() ([]byte, []int) {
+	return file_account_mfa_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TOTPCodeRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type RecoveryCodesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RecoveryCodes []string               `protobuf:"bytes,1,rep,name=recovery_codes,json=recoveryCodes,proto3" json:"recovery_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecoveryCodesResponse) Reset() {
+	*x = RecoveryCodesResponse{}
+	mi := &file_account_mfa_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecoveryCodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecoveryCodesResponse) ProtoMessage() {}
+
+func (x *RecoveryCodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_mfa_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecoveryCodesResponse.ProtoReflect.Descriptor instead.
+func (*RecoveryCodesResponse) Descriptor() ([]byte, []int) {
+	return file_account_mfa_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RecoveryCodesResponse) GetRecoveryCodes() []string {
+	if x != nil {
+		return x.RecoveryCodes
+	}
+	return nil
+}
+
+// Второй шаг входа: mfa_token приходит в ErrorInfo ответа Login (reason MFA_REQUIRED)
+type VerifyMFARequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MfaToken      string                 `protobuf:"bytes,1,opt,name=mfa_token,json=mfaToken,proto3" json:"mfa_token,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"` // код из приложения или recovery code
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyMFARequest) Reset() {
+	*x = VerifyMFARequest{}
+	mi := &file_account_mfa_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyMFARequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyMFARequest) ProtoMessage() {}
+
+func (x *VerifyMFARequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_mfa_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyMFARequest.ProtoReflect.Descriptor instead.
+func (*VerifyMFARequest) Descriptor() ([]byte, []int) {
+	return file_account_mfa_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyMFARequest) GetMfaToken() string {
+	if x != nil {
+		return x.MfaToken
+	}
+	return ""
+}
+
+func (x *VerifyMFARequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type VerifyMFAResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tokens        *user_service.Tokens   `protobuf:"bytes,1,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyMFAResponse) Reset() {
+	*x = VerifyMFAResponse{}
+	mi := &file_account_mfa_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyMFAResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyMFAResponse) ProtoMessage() {}
+
+func (x *VerifyMFAResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_mfa_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyMFAResponse.ProtoReflect.Descriptor instead.
+func (*VerifyMFAResponse) Descriptor() ([]byte, []int) {
+	return file_account_mfa_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VerifyMFAResponse) GetTokens() *user_service.Tokens {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+var File_account_mfa_proto protoreflect.FileDescriptor
+
+const file_account_mfa_proto_rawDesc = "" +
+	"\n" +
+	"\x11account/mfa.proto\x12\fuser_profile\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fuser-service/user_service.proto\"t\n" +
+	"\x12EnrollTOTPResponse\x12\x16\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret\x12\x1f\n" +
+	"\votpauth_uri\x18\x02 \x01(\tR\n" +
+	"otpauthUri\x12%\n" +
+	"\x0erecovery_codes\x18\x03 \x03(\tR\rrecoveryCodes\"%\n" +
+	"\x0fTOTPCodeRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\">\n" +
+	"\x15RecoveryCodesResponse\x12%\n" +
+	"\x0erecovery_codes\x18\x01 \x03(\tR\rrecoveryCodes\"C\n" +
+	"\x10VerifyMFARequest\x12\x1b\n" +
+	"\tmfa_token\x18\x01 \x01(\tR\bmfaToken\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\"A\n" +
+	"\x11VerifyMFAResponse\x12,\n" +
+	"\x06tokens\x18\x01 \x01(\v2\x14.user_profile.TokensR\x06tokens2\x8d\x03\n" +
+	"\n" +
+	"MFAService\x12F\n" +
+	"\n" +
+	"EnrollTOTP\x12\x16.google.protobuf.Empty\x1a .user_profile.EnrollTOTPResponse\x12D\n" +
+	"\vConfirmTOTP\x12\x1d.user_profile.TOTPCodeRequest\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\vDisableTOTP\x12\x1d.user_profile.TOTPCodeRequest\x1a\x16.google.protobuf.Empty\x12L\n" +
+	"\tVerifyMFA\x12\x1e.user_profile.VerifyMFARequest\x1a\x1f.user_profile.VerifyMFAResponse\x12]\n" +
+	"\x17RegenerateRecoveryCodes\x12\x1d.user_profile.TOTPCodeRequest\x1a#.user_profile.RecoveryCodesResponseB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_mfa_proto_rawDescOnce sync.Once
+	file_account_mfa_proto_rawDescData []byte
+)
+
+func file_account_mfa_proto_rawDescGZIP() []byte {
+	file_account_mfa_proto_rawDescOnce.Do(func() {
+		file_account_mfa_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_mfa_proto_rawDesc), len(file_account_mfa_proto_rawDesc)))
+	})
+	return file_account_mfa_proto_rawDescData
+}
+
+var file_account_mfa_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_account_mfa_proto_goTypes = []any{
+	(*EnrollTOTPResponse)(nil),    // 0: user_profile.EnrollTOTPResponse
+	(*TOTPCodeRequest)(nil),       // 1: user_profile.TOTPCodeRequest
+	(*RecoveryCodesResponse)(nil), // 2: user_profile.RecoveryCodesResponse
+	(*VerifyMFARequest)(nil),      // 3: user_profile.VerifyMFARequest
+	(*VerifyMFAResponse)(nil),     // 4: user_profile.VerifyMFAResponse
+	(*user_service.Tokens)(nil),   // 5: user_profile.Tokens
+	(*emptypb.Empty)(nil),         // 6: google.protobuf.Empty
+}
+var file_account_mfa_proto_depIdxs = []int32{
+	5, // 0: user_profile.VerifyMFAResponse.tokens:type_name -> user_profile.Tokens
+	6, // 1: user_profile.MFAService.EnrollTOTP:input_type -> google.protobuf.Empty
+	1, // 2: user_profile.MFAService.ConfirmTOTP:input_type -> user_profile.TOTPCodeRequest
+	1, // 3: user_profile.MFAService.DisableTOTP:input_type -> user_profile.TOTPCodeRequest
+	3, // 4: user_profile.MFAService.VerifyMFA:input_type -> user_profile.VerifyMFARequest
+	1, // 5: user_profile.MFAService.RegenerateRecoveryCodes:input_type -> user_profile.TOTPCodeRequest
+	0, // 6: user_profile.MFAService.EnrollTOTP:output_type -> user_profile.EnrollTOTPResponse
+	6, // 7: user_profile.MFAService.ConfirmTOTP:output_type -> google.protobuf.Empty
+	6, // 8: user_profile.MFAService.DisableTOTP:output_type -> google.protobuf.Empty
+	4, // 9: user_profile.MFAService.VerifyMFA:output_type -> user_profile.VerifyMFAResponse
+	2, // 10: user_profile.MFAService.RegenerateRecoveryCodes:output_type -> user_profile.RecoveryCodesResponse
+	6, // [6:11] is the sub-list for method output_type
+	1, // [1:6] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_account_mfa_proto_init() }
+func file_account_mfa_proto_init() {
+	if File_account_mfa_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_mfa_proto_rawDesc), len(file_account_mfa_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_mfa_proto_goTypes,
+		DependencyIndexes: file_account_mfa_proto_depIdxs,
+		MessageInfos:      file_account_mfa_proto_msgTypes,
+	}.Build()
+	File_account_mfa_proto = out.File
+	file_account_mfa_proto_goTypes = nil
+	file_account_mfa_proto_depIdxs = nil
+}
@@ -0,0 +1,276 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.31.1
+// source: account/mfa.proto
+
+package accountv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MFAService_EnrollTOTP_FullMethodName              = "/user_profile.MFAService/EnrollTOTP"
+	MFAService_ConfirmTOTP_FullMethodName             = "/user_profile.MFAService/ConfirmTOTP"
+	MFAService_DisableTOTP_FullMethodName             = "/user_profile.MFAService/DisableTOTP"
+	MFAService_VerifyMFA_FullMethodName               = "/user_profile.MFAService/VerifyMFA"
+	MFAService_RegenerateRecoveryCodes_FullMethodName = "/user_profile.MFAService/RegenerateRecoveryCodes"
+)
+
+// MFAServiceClient is the client API for MFAService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MFAServiceClient interface {
+	EnrollTOTP(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*EnrollTOTPResponse, error)
+	ConfirmTOTP(ctx context.Context, in *TOTPCodeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DisableTOTP(ctx context.Context, in *TOTPCodeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	VerifyMFA(ctx context.Context, in *VerifyMFARequest, opts ...grpc.CallOption) (*VerifyMFAResponse, error)
+	// Выдаёт новый набор recovery codes, старые перестают действовать
+	RegenerateRecoveryCodes(ctx context.Context, in *TOTPCodeRequest, opts ...grpc.CallOption) (*RecoveryCodesResponse, error)
+}
+
+type mFAServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMFAServiceClient(cc grpc.ClientConnInterface) MFAServiceClient {
+	return &mFAServiceClient{cc}
+}
+
+func (c *mFAServiceClient) EnrollTOTP(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*EnrollTOTPResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnrollTOTPResponse)
+	err := c.cc.Invoke(ctx, MFAService_EnrollTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mFAServiceClient) ConfirmTOTP(ctx context.Context, in *TOTPCodeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, MFAService_ConfirmTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mFAServiceClient) DisableTOTP(ctx context.Context, in *TOTPCodeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, MFAService_DisableTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mFAServiceClient) VerifyMFA(ctx context.Context, in *VerifyMFARequest, opts ...grpc.CallOption) (*VerifyMFAResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyMFAResponse)
+	err := c.cc.Invoke(ctx, MFAService_VerifyMFA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mFAServiceClient) RegenerateRecoveryCodes(ctx context.Context, in *TOTPCodeRequest, opts ...grpc.CallOption) (*RecoveryCodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecoveryCodesResponse)
+	err := c.cc.Invoke(ctx, MFAService_RegenerateRecoveryCodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MFAServiceServer is the server API for MFAService service.
+// All implementations must embed UnimplementedMFAServiceServer
+// for forward compatibility.
+type MFAServiceServer interface {
+	EnrollTOTP(context.Context, *emptypb.Empty) (*EnrollTOTPResponse, error)
+	ConfirmTOTP(context.Context, *TOTPCodeRequest) (*emptypb.Empty, error)
+	DisableTOTP(context.Context, *TOTPCodeRequest) (*emptypb.Empty, error)
+	VerifyMFA(context.Context, *VerifyMFARequest) (*VerifyMFAResponse, error)
+	// Выдаёт новый набор recovery codes, старые перестают действовать
+	RegenerateRecoveryCodes(context.Context, *TOTPCodeRequest) (*RecoveryCodesResponse, error)
+	mustEmbedUnimplementedMFAServiceServer()
+}
+
+// UnimplementedMFAServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMFAServiceServer struct{}
+
+func (UnimplementedMFAServiceServer) EnrollTOTP(context.Context, *emptypb.Empty) (*EnrollTOTPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTOTP not implemented")
+}
+func (UnimplementedMFAServiceServer) ConfirmTOTP(context.Context, *TOTPCodeRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTOTP not implemented")
+}
+func (UnimplementedMFAServiceServer) DisableTOTP(context.Context, *TOTPCodeRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTOTP not implemented")
+}
+func (UnimplementedMFAServiceServer) VerifyMFA(context.Context, *VerifyMFARequest) (*VerifyMFAResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyMFA not implemented")
+}
+func (UnimplementedMFAServiceServer) RegenerateRecoveryCodes(context.Context, *TOTPCodeRequest) (*RecoveryCodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegenerateRecoveryCodes not implemented")
+}
+func (UnimplementedMFAServiceServer) mustEmbedUnimplementedMFAServiceServer() {}
+func (UnimplementedMFAServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeMFAServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MFAServiceServer will
+// result in compilation errors.
+type UnsafeMFAServiceServer interface {
+	mustEmbedUnimplementedMFAServiceServer()
+}
+
+func RegisterMFAServiceServer(s grpc.ServiceRegistrar, srv MFAServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMFAServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MFAService_ServiceDesc, srv)
+}
+
+func _MFAService_EnrollTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).EnrollTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_EnrollTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).EnrollTOTP(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MFAService_ConfirmTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).ConfirmTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_ConfirmTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).ConfirmTOTP(ctx, req.(*TOTPCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MFAService_DisableTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).DisableTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_DisableTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).DisableTOTP(ctx, req.(*TOTPCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MFAService_VerifyMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyMFARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).VerifyMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_VerifyMFA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).VerifyMFA(ctx, req.(*VerifyMFARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MFAService_RegenerateRecoveryCodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MFAServiceServer).RegenerateRecoveryCodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MFAService_RegenerateRecoveryCodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MFAServiceServer).RegenerateRecoveryCodes(ctx, req.(*TOTPCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MFAService_ServiceDesc is the grpc.ServiceDesc for MFAService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MFAService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user_profile.MFAService",
+	HandlerType: (*MFAServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "EnrollTOTP",
+			Handler:    _MFAService_EnrollTOTP_Handler,
+		},
+		{
+			MethodName: "ConfirmTOTP",
+			Handler:    _MFAService_ConfirmTOTP_Handler,
+		},
+		{
+			MethodName: "DisableTOTP",
+			Handler:    _MFAService_DisableTOTP_Handler,
+		},
+		{
+			MethodName: "VerifyMFA",
+			Handler:    _MFAService_VerifyMFA_Handler,
+		},
+		{
+			MethodName: "RegenerateRecoveryCodes",
+			Handler:    _MFAService_RegenerateRecoveryCodes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "account/mfa.proto",
+}
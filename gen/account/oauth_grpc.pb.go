@@ -0,0 +1,164 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.31.1
+// source: account/oauth.proto
+
+package accountv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OAuthClientService_CreateOAuthClient_FullMethodName = "/user_profile.OAuthClientService/CreateOAuthClient"
+	OAuthClientService_DeleteOAuthClient_FullMethodName = "/user_profile.OAuthClientService/DeleteOAuthClient"
+)
+
+// OAuthClientServiceClient is the client API for OAuthClientService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Логика админа
+type OAuthClientServiceClient interface {
+	CreateOAuthClient(ctx context.Context, in *CreateOAuthClientRequest, opts ...grpc.CallOption) (*CreateOAuthClientResponse, error)
+	DeleteOAuthClient(ctx context.Context, in *DeleteOAuthClientRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type oAuthClientServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOAuthClientServiceClient(cc grpc.ClientConnInterface) OAuthClientServiceClient {
+	return &oAuthClientServiceClient{cc}
+}
+
+func (c *oAuthClientServiceClient) CreateOAuthClient(ctx context.Context, in *CreateOAuthClientRequest, opts ...grpc.CallOption) (*CreateOAuthClientResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateOAuthClientResponse)
+	err := c.cc.Invoke(ctx, OAuthClientService_CreateOAuthClient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oAuthClientServiceClient) DeleteOAuthClient(ctx context.Context, in *DeleteOAuthClientRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, OAuthClientService_DeleteOAuthClient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OAuthClientServiceServer is the server API for OAuthClientService service.
+// All implementations must embed UnimplementedOAuthClientServiceServer
+// for forward compatibility.
+//
+// Логика админа
+type OAuthClientServiceServer interface {
+	CreateOAuthClient(context.Context, *CreateOAuthClientRequest) (*CreateOAuthClientResponse, error)
+	DeleteOAuthClient(context.Context, *DeleteOAuthClientRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedOAuthClientServiceServer()
+}
+
+// UnimplementedOAuthClientServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOAuthClientServiceServer struct{}
+
+func (UnimplementedOAuthClientServiceServer) CreateOAuthClient(context.Context, *CreateOAuthClientRequest) (*CreateOAuthClientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOAuthClient not implemented")
+}
+func (UnimplementedOAuthClientServiceServer) DeleteOAuthClient(context.Context, *DeleteOAuthClientRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteOAuthClient not implemented")
+}
+func (UnimplementedOAuthClientServiceServer) mustEmbedUnimplementedOAuthClientServiceServer() {}
+func (UnimplementedOAuthClientServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeOAuthClientServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OAuthClientServiceServer will
+// result in compilation errors.
+type UnsafeOAuthClientServiceServer interface {
+	mustEmbedUnimplementedOAuthClientServiceServer()
+}
+
+func RegisterOAuthClientServiceServer(s grpc.ServiceRegistrar, srv OAuthClientServiceServer) {
+	// If the following call pancis, it indicates UnimplementedOAuthClientServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OAuthClientService_ServiceDesc, srv)
+}
+
+func _OAuthClientService_CreateOAuthClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOAuthClientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OAuthClientServiceServer).CreateOAuthClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OAuthClientService_CreateOAuthClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OAuthClientServiceServer).CreateOAuthClient(ctx, req.(*CreateOAuthClientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OAuthClientService_DeleteOAuthClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteOAuthClientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OAuthClientServiceServer).DeleteOAuthClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OAuthClientService_DeleteOAuthClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OAuthClientServiceServer).DeleteOAuthClient(ctx, req.(*DeleteOAuthClientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OAuthClientService_ServiceDesc is the grpc.ServiceDesc for OAuthClientService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OAuthClientService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user_profile.OAuthClientService",
+	HandlerType: (*OAuthClientServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateOAuthClient",
+			Handler:    _OAuthClientService_CreateOAuthClient_Handler,
+		},
+		{
+			MethodName: "DeleteOAuthClient",
+			Handler:    _OAuthClientService_DeleteOAuthClient_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "account/oauth.proto",
+}
@@ -0,0 +1,603 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/passkey.proto
+
+package accountv1
+
+import (
+	user_service "github.com/AronditFire/UService-ProtobufNew/gen/user-service"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Параметры для navigator.credentials.create(), байтовые поля клиент передаёт как ArrayBuffer
+type BeginPasskeyRegistrationResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Challenge          []byte                 `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	RpId               string                 `protobuf:"bytes,2,opt,name=rp_id,json=rpId,proto3" json:"rp_id,omitempty"`
+	RpName             string                 `protobuf:"bytes,3,opt,name=rp_name,json=rpName,proto3" json:"rp_name,omitempty"`
+	UserHandle         []byte                 `protobuf:"bytes,4,opt,name=user_handle,json=userHandle,proto3" json:"user_handle,omitempty"`
+	UserName           string                 `protobuf:"bytes,5,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	UserDisplayName    string                 `protobuf:"bytes,6,opt,name=user_display_name,json=userDisplayName,proto3" json:"user_display_name,omitempty"`
+	Algorithms         []int64                `protobuf:"varint,7,rep,packed,name=algorithms,proto3" json:"algorithms,omitempty"` // COSE, в порядке предпочтения
+	ExcludeCredentials [][]byte               `protobuf:"bytes,8,rep,name=exclude_credentials,json=excludeCredentials,proto3" json:"exclude_credentials,omitempty"`
+	TimeoutMs          int64                  `protobuf:"varint,9,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	UserVerification   string                 `protobuf:"bytes,10,opt,name=user_verification,json=userVerification,proto3" json:"user_verification,omitempty"` // required | preferred
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *BeginPasskeyRegistrationResponse) Reset() {
+	*x = BeginPasskeyRegistrationResponse{}
+	mi := &file_account_passkey_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BeginPasskeyRegistrationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeginPasskeyRegistrationResponse) ProtoMessage() {}
+
+func (x *BeginPasskeyRegistrationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeginPasskeyRegistrationResponse.ProtoReflect.Descriptor instead.
+func (*BeginPasskeyRegistrationResponse) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetChallenge() []byte {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetRpId() string {
+	if x != nil {
+		return x.RpId
+	}
+	return ""
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetRpName() string {
+	if x != nil {
+		return x.RpName
+	}
+	return ""
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetUserHandle() []byte {
+	if x != nil {
+		return x.UserHandle
+	}
+	return nil
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetUserDisplayName() string {
+	if x != nil {
+		return x.UserDisplayName
+	}
+	return ""
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetAlgorithms() []int64 {
+	if x != nil {
+		return x.Algorithms
+	}
+	return nil
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetExcludeCredentials() [][]byte {
+	if x != nil {
+		return x.ExcludeCredentials
+	}
+	return nil
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+func (x *BeginPasskeyRegistrationResponse) GetUserVerification() string {
+	if x != nil {
+		return x.UserVerification
+	}
+	return ""
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Name              string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // название ключа для пользователя
+	ClientDataJson    []byte                 `protobuf:"bytes,2,opt,name=client_data_json,json=clientDataJson,proto3" json:"client_data_json,omitempty"`
+	AttestationObject []byte                 `protobuf:"bytes,3,opt,name=attestation_object,json=attestationObject,proto3" json:"attestation_object,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *FinishPasskeyRegistrationRequest) Reset() {
+	*x = FinishPasskeyRegistrationRequest{}
+	mi := &file_account_passkey_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinishPasskeyRegistrationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinishPasskeyRegistrationRequest) ProtoMessage() {}
+
+func (x *FinishPasskeyRegistrationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinishPasskeyRegistrationRequest.ProtoReflect.Descriptor instead.
+func (*FinishPasskeyRegistrationRequest) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FinishPasskeyRegistrationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FinishPasskeyRegistrationRequest) GetClientDataJson() []byte {
+	if x != nil {
+		return x.ClientDataJson
+	}
+	return nil
+}
+
+func (x *FinishPasskeyRegistrationRequest) GetAttestationObject() []byte {
+	if x != nil {
+		return x.AttestationObject
+	}
+	return nil
+}
+
+type FinishPasskeyRegistrationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CredentialId  []byte                 `protobuf:"bytes,1,opt,name=credential_id,json=credentialId,proto3" json:"credential_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinishPasskeyRegistrationResponse) Reset() {
+	*x = FinishPasskeyRegistrationResponse{}
+	mi := &file_account_passkey_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinishPasskeyRegistrationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinishPasskeyRegistrationResponse) ProtoMessage() {}
+
+func (x *FinishPasskeyRegistrationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinishPasskeyRegistrationResponse.ProtoReflect.Descriptor instead.
+func (*FinishPasskeyRegistrationResponse) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FinishPasskeyRegistrationResponse) GetCredentialId() []byte {
+	if x != nil {
+		return x.CredentialId
+	}
+	return nil
+}
+
+type BeginPasskeyLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Login         string                 `protobuf:"bytes,1,opt,name=login,proto3" json:"login,omitempty"` // необязателен, без него используются discoverable credentials
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BeginPasskeyLoginRequest) Reset() {
+	*x = BeginPasskeyLoginRequest{}
+	mi := &file_account_passkey_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BeginPasskeyLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeginPasskeyLoginRequest) ProtoMessage() {}
+
+func (x *BeginPasskeyLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeginPasskeyLoginRequest.ProtoReflect.Descriptor instead.
+func (*BeginPasskeyLoginRequest) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BeginPasskeyLoginRequest) GetLogin() string {
+	if x != nil {
+		return x.Login
+	}
+	return ""
+}
+
+// Параметры для navigator.credentials.get()
+type BeginPasskeyLoginResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Challenge        []byte                 `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	RpId             string                 `protobuf:"bytes,2,opt,name=rp_id,json=rpId,proto3" json:"rp_id,omitempty"`
+	AllowCredentials [][]byte               `protobuf:"bytes,3,rep,name=allow_credentials,json=allowCredentials,proto3" json:"allow_credentials,omitempty"`
+	TimeoutMs        int64                  `protobuf:"varint,4,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	UserVerification string                 `protobuf:"bytes,5,opt,name=user_verification,json=userVerification,proto3" json:"user_verification,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *BeginPasskeyLoginResponse) Reset() {
+	*x = BeginPasskeyLoginResponse{}
+	mi := &file_account_passkey_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BeginPasskeyLoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeginPasskeyLoginResponse) ProtoMessage() {}
+
+func (x *BeginPasskeyLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeginPasskeyLoginResponse.ProtoReflect.Descriptor instead.
+func (*BeginPasskeyLoginResponse) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BeginPasskeyLoginResponse) GetChallenge() []byte {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+func (x *BeginPasskeyLoginResponse) GetRpId() string {
+	if x != nil {
+		return x.RpId
+	}
+	return ""
+}
+
+func (x *BeginPasskeyLoginResponse) GetAllowCredentials() [][]byte {
+	if x != nil {
+		return x.AllowCredentials
+	}
+	return nil
+}
+
+func (x *BeginPasskeyLoginResponse) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+func (x *BeginPasskeyLoginResponse) GetUserVerification() string {
+	if x != nil {
+		return x.UserVerification
+	}
+	return ""
+}
+
+type FinishPasskeyLoginRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	CredentialId      []byte                 `protobuf:"bytes,1,opt,name=credential_id,json=credentialId,proto3" json:"credential_id,omitempty"`
+	ClientDataJson    []byte                 `protobuf:"bytes,2,opt,name=client_data_json,json=clientDataJson,proto3" json:"client_data_json,omitempty"`
+	AuthenticatorData []byte                 `protobuf:"bytes,3,opt,name=authenticator_data,json=authenticatorData,proto3" json:"authenticator_data,omitempty"`
+	Signature         []byte                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	UserHandle        []byte                 `protobuf:"bytes,5,opt,name=user_handle,json=userHandle,proto3" json:"user_handle,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *FinishPasskeyLoginRequest) Reset() {
+	*x = FinishPasskeyLoginRequest{}
+	mi := &file_account_passkey_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinishPasskeyLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinishPasskeyLoginRequest) ProtoMessage() {}
+
+func (x *FinishPasskeyLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinishPasskeyLoginRequest.ProtoReflect.Descriptor instead.
+func (*FinishPasskeyLoginRequest) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FinishPasskeyLoginRequest) GetCredentialId() []byte {
+	if x != nil {
+		return x.CredentialId
+	}
+	return nil
+}
+
+func (x *FinishPasskeyLoginRequest) GetClientDataJson() []byte {
+	if x != nil {
+		return x.ClientDataJson
+	}
+	return nil
+}
+
+func (x *FinishPasskeyLoginRequest) GetAuthenticatorData() []byte {
+	if x != nil {
+		return x.AuthenticatorData
+	}
+	return nil
+}
+
+func (x *FinishPasskeyLoginRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *FinishPasskeyLoginRequest) GetUserHandle() []byte {
+	if x != nil {
+		return x.UserHandle
+	}
+	return nil
+}
+
+// Если ключ не проверил пользователя и включена 2FA, вернётся ошибка MFA_REQUIRED как у Login
+type FinishPasskeyLoginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tokens        *user_service.Tokens   `protobuf:"bytes,1,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinishPasskeyLoginResponse) Reset() {
+	*x = FinishPasskeyLoginResponse{}
+	mi := &file_account_passkey_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinishPasskeyLoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinishPasskeyLoginResponse) ProtoMessage() {}
+
+func (x *FinishPasskeyLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_passkey_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinishPasskeyLoginResponse.ProtoReflect.Descriptor instead.
+func (*FinishPasskeyLoginResponse) Descriptor() ([]byte, []int) {
+	return file_account_passkey_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FinishPasskeyLoginResponse) GetTokens() *user_service.Tokens {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+var File_account_passkey_proto protoreflect.FileDescriptor
+
+const file_account_passkey_proto_rawDesc = "" +
+	"\n" +
+	"\x15account/passkey.proto\x12\fuser_profile\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fuser-service/user_service.proto\"\xf5\x02\n" +
+	" BeginPasskeyRegistrationResponse\x12\x1c\n" +
+	"\tchallenge\x18\x01 \x01(\fR\tchallenge\x12\x13\n" +
+	"\x05rp_id\x18\x02 \x01(\tR\x04rpId\x12\x17\n" +
+	"\arp_name\x18\x03 \x01(\tR\x06rpName\x12\x1f\n" +
+	"\vuser_handle\x18\x04 \x01(\fR\n" +
+	"userHandle\x12\x1b\n" +
+	"\tuser_name\x18\x05 \x01(\tR\buserName\x12*\n" +
+	"\x11user_display_name\x18\x06 \x01(\tR\x0fuserDisplayName\x12\x1e\n" +
+	"\n" +
+	"algorithms\x18\a \x03(\x03R\n" +
+	"algorithms\x12/\n" +
+	"\x13exclude_credentials\x18\b \x03(\fR\x12excludeCredentials\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\t \x01(\x03R\ttimeoutMs\x12+\n" +
+	"\x11user_verification\x18\n" +
+	" \x01(\tR\x10userVerification\"\x8f\x01\n" +
+	" FinishPasskeyRegistrationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12(\n" +
+	"\x10client_data_json\x18\x02 \x01(\fR\x0eclientDataJson\x12-\n" +
+	"\x12attestation_object\x18\x03 \x01(\fR\x11attestationObject\"H\n" +
+	"!FinishPasskeyRegistrationResponse\x12#\n" +
+	"\rcredential_id\x18\x01 \x01(\fR\fcredentialId\"0\n" +
+	"\x18BeginPasskeyLoginRequest\x12\x14\n" +
+	"\x05login\x18\x01 \x01(\tR\x05login\"\xc7\x01\n" +
+	"\x19BeginPasskeyLoginResponse\x12\x1c\n" +
+	"\tchallenge\x18\x01 \x01(\fR\tchallenge\x12\x13\n" +
+	"\x05rp_id\x18\x02 \x01(\tR\x04rpId\x12+\n" +
+	"\x11allow_credentials\x18\x03 \x03(\fR\x10allowCredentials\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x04 \x01(\x03R\ttimeoutMs\x12+\n" +
+	"\x11user_verification\x18\x05 \x01(\tR\x10userVerification\"\xd8\x01\n" +
+	"\x19FinishPasskeyLoginRequest\x12#\n" +
+	"\rcredential_id\x18\x01 \x01(\fR\fcredentialId\x12(\n" +
+	"\x10client_data_json\x18\x02 \x01(\fR\x0eclientDataJson\x12-\n" +
+	"\x12authenticator_data\x18\x03 \x01(\fR\x11authenticatorData\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\fR\tsignature\x12\x1f\n" +
+	"\vuser_handle\x18\x05 \x01(\fR\n" +
+	"userHandle\"J\n" +
+	"\x1aFinishPasskeyLoginResponse\x12,\n" +
+	"\x06tokens\x18\x01 \x01(\v2\x14.user_profile.TokensR\x06tokens2\xc1\x03\n" +
+	"\x0ePasskeyService\x12b\n" +
+	"\x18BeginPasskeyRegistration\x12\x16.google.protobuf.Empty\x1a..user_profile.BeginPasskeyRegistrationResponse\x12|\n" +
+	"\x19FinishPasskeyRegistration\x12..user_profile.FinishPasskeyRegistrationRequest\x1a/.user_profile.FinishPasskeyRegistrationResponse\x12d\n" +
+	"\x11BeginPasskeyLogin\x12&.user_profile.BeginPasskeyLoginRequest\x1a'.user_profile.BeginPasskeyLoginResponse\x12g\n" +
+	"\x12FinishPasskeyLogin\x12'.user_profile.FinishPasskeyLoginRequest\x1a(.user_profile.FinishPasskeyLoginResponseB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_passkey_proto_rawDescOnce sync.Once
+	file_account_passkey_proto_rawDescData []byte
+)
+
+func file_account_passkey_proto_rawDescGZIP() []byte {
+	file_account_passkey_proto_rawDescOnce.Do(func() {
+		file_account_passkey_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_passkey_proto_rawDesc), len(file_account_passkey_proto_rawDesc)))
+	})
+	return file_account_passkey_proto_rawDescData
+}
+
+var file_account_passkey_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_account_passkey_proto_goTypes = []any{
+	(*BeginPasskeyRegistrationResponse)(nil),  // 0: user_profile.BeginPasskeyRegistrationResponse
+	(*FinishPasskeyRegistrationRequest)(nil),  // 1: user_profile.FinishPasskeyRegistrationRequest
+	(*FinishPasskeyRegistrationResponse)(nil), // 2: user_profile.FinishPasskeyRegistrationResponse
+	(*BeginPasskeyLoginRequest)(nil),          // 3: user_profile.BeginPasskeyLoginRequest
+	(*BeginPasskeyLoginResponse)(nil),         // 4: user_profile.BeginPasskeyLoginResponse
+	(*FinishPasskeyLoginRequest)(nil),         // 5: user_profile.FinishPasskeyLoginRequest
+	(*FinishPasskeyLoginResponse)(nil),        // 6: user_profile.FinishPasskeyLoginResponse
+	(*user_service.Tokens)(nil),               // 7: user_profile.Tokens
+	(*emptypb.Empty)(nil),                     // 8: google.protobuf.Empty
+}
+var file_account_passkey_proto_depIdxs = []int32{
+	7, // 0: user_profile.FinishPasskeyLoginResponse.tokens:type_name -> user_profile.Tokens
+	8, // 1: user_profile.PasskeyService.BeginPasskeyRegistration:input_type -> google.protobuf.Empty
+	1, // 2: user_profile.PasskeyService.FinishPasskeyRegistration:input_type -> user_profile.FinishPasskeyRegistrationRequest
+	3, // 3: user_profile.PasskeyService.BeginPasskeyLogin:input_type -> user_profile.BeginPasskeyLoginRequest
+	5, // 4: user_profile.PasskeyService.FinishPasskeyLogin:input_type -> user_profile.FinishPasskeyLoginRequest
+	0, // 5: user_profile.PasskeyService.BeginPasskeyRegistration:output_type -> user_profile.BeginPasskeyRegistrationResponse
+	2, // 6: user_profile.PasskeyService.FinishPasskeyRegistration:output_type -> user_profile.FinishPasskeyRegistrationResponse
+	4, // 7: user_profile.PasskeyService.BeginPasskeyLogin:output_type -> user_profile.BeginPasskeyLoginResponse
+	6, // 8: user_profile.PasskeyService.FinishPasskeyLogin:output_type -> user_profile.FinishPasskeyLoginResponse
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_account_passkey_proto_init() }
+func file_account_passkey_proto_init() {
+	if File_account_passkey_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_passkey_proto_rawDesc), len(file_account_passkey_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_passkey_proto_goTypes,
+		DependencyIndexes: file_account_passkey_proto_depIdxs,
+		MessageInfos:      file_account_passkey_proto_msgTypes,
+	}.Build()
+	File_account_passkey_proto = out.File
+	file_account_passkey_proto_goTypes = nil
+	file_account_passkey_proto_depIdxs = nil
+}
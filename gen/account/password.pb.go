@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/password.proto
+
+package accountv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Ответ одинаковый для любого email, чтобы нельзя было проверить наличие аккаунта
+type RequestPasswordResetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestPasswordResetRequest) Reset() {
+	*x = RequestPasswordResetRequest{}
+	mi := &file_account_password_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestPasswordResetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestPasswordResetRequest) ProtoMessage() {}
+
+func (x *RequestPasswordResetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_password_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestPasswordResetRequest.ProtoReflect.Descriptor instead.
+func (*RequestPasswordResetRequest) Descriptor() ([]byte, []int) {
+	return file_account_password_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RequestPasswordResetRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+// После сброса все сессии пользователя завершаются
+type ResetPasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"` // из ссылки в письме
+	NewPassword   string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetPasswordRequest) Reset() {
+	*x = ResetPasswordRequest{}
+	mi := &file_account_password_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetPasswordRequest) ProtoMessage() {}
+
+func (x *ResetPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_password_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetPasswordRequest.ProtoReflect.Descriptor instead.
+func (*ResetPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_account_password_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ResetPasswordRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ResetPasswordRequest) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+type ChangePasswordRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	CurrentPassword    string                 `protobuf:"bytes,1,opt,name=current_password,json=currentPassword,proto3" json:"current_password,omitempty"`
+	NewPassword        string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	KeepCurrentSession bool                   `protobuf:"varint,3,opt,name=keep_current_session,json=keepCurrentSession,proto3" json:"keep_current_session,omitempty"` // остальные сессии завершаются в любом случае
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ChangePasswordRequest) Reset() {
+	*x = ChangePasswordRequest{}
+	mi := &file_account_password_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordRequest) ProtoMessage() {}
+
+func (x *ChangePasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_password_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordRequest.ProtoReflect.Descriptor instead.
+func (*ChangePasswordRequest) Descriptor() ([]byte, []int) {
+	return file_account_password_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ChangePasswordRequest) GetCurrentPassword() string {
+	if x != nil {
+		return x.CurrentPassword
+	}
+	return ""
+}
+
+func (x *ChangePasswordRequest) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+func (x *ChangePasswordRequest) GetKeepCurrentSession() bool {
+	if x != nil {
+		return x.KeepCurrentSession
+	}
+	return false
+}
+
+var File_account_password_proto protoreflect.FileDescriptor
+
+const file_account_password_proto_rawDesc = "" +
+	"\n" +
+	"\x16account/password.proto\x12\fuser_profile\x1a\x1bgoogle/protobuf/empty.proto\"3\n" +
+	"\x1bRequestPasswordResetRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\"O\n" +
+	"\x14ResetPasswordRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\"\x97\x01\n" +
+	"\x15ChangePasswordRequest\x12)\n" +
+	"\x10current_password\x18\x01 \x01(\tR\x0fcurrentPassword\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\x120\n" +
+	"\x14keep_current_session\x18\x03 \x01(\bR\x12keepCurrentSession2\x88\x02\n" +
+	"\x0fPasswordService\x12Y\n" +
+	"\x14RequestPasswordReset\x12).user_profile.RequestPasswordResetRequest\x1a\x16.google.protobuf.Empty\x12K\n" +
+	"\rResetPassword\x12\".user_profile.ResetPasswordRequest\x1a\x16.google.protobuf.Empty\x12M\n" +
+	"\x0eChangePassword\x12#.user_profile.ChangePasswordRequest\x1a\x16.google.protobuf.EmptyB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_password_proto_rawDescOnce sync.Once
+	file_account_password_proto_rawDescData []byte
+)
+
+func file_account_password_proto_rawDescGZIP() []byte {
+	file_account_password_proto_rawDescOnce.Do(func() {
+		file_account_password_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_password_proto_rawDesc), len(file_account_password_proto_rawDesc)))
+	})
+	return file_account_password_proto_rawDescData
+}
+
+var file_account_password_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_account_password_proto_goTypes = []any{
+	(*RequestPasswordResetRequest)(nil), // 0: user_profile.RequestPasswordResetRequest
+	(*ResetPasswordRequest)(nil),        // 1: user_profile.ResetPasswordRequest
+	(*ChangePasswordRequest)(nil),       // 2: user_profile.ChangePasswordRequest
+	(*emptypb.Empty)(nil),               // 3: google.protobuf.Empty
+}
+var file_account_password_proto_depIdxs = []int32{
+	0, // 0: user_profile.PasswordService.RequestPasswordReset:input_type -> user_profile.RequestPasswordResetRequest
+	1, // 1: user_profile.PasswordService.ResetPassword:input_type -> user_profile.ResetPasswordRequest
+	2, // 2: user_profile.PasswordService.ChangePassword:input_type -> user_profile.ChangePasswordRequest
+	3, // 3: user_profile.PasswordService.RequestPasswordReset:output_type -> google.protobuf.Empty
+	3, // 4: user_profile.PasswordService.ResetPassword:output_type -> google.protobuf.Empty
+	3, // 5: user_profile.PasswordService.ChangePassword:output_type -> google.protobuf.Empty
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_account_password_proto_init() }
+func file_account_password_proto_init() {
+	if File_account_password_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_password_proto_rawDesc), len(file_account_password_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_password_proto_goTypes,
+		DependencyIndexes: file_account_password_proto_depIdxs,
+		MessageInfos:      file_account_password_proto_msgTypes,
+	}.Build()
+	File_account_password_proto = out.File
+	file_account_password_proto_goTypes = nil
+	file_account_password_proto_depIdxs = nil
+}
@@ -0,0 +1,284 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: account/verification.proto
+
+package accountv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Ответ одинаковый для любого email, чтобы нельзя было проверить наличие аккаунта
+type SendVerificationEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendVerificationEmailRequest) Reset() {
+	*x = SendVerificationEmailRequest{}
+	mi := &file_account_verification_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendVerificationEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendVerificationEmailRequest) ProtoMessage() {}
+
+func (x *SendVerificationEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_verification_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendVerificationEmailRequest.ProtoReflect.Descriptor instead.
+func (*SendVerificationEmailRequest) Descriptor() ([]byte, []int) {
+	return file_account_verification_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SendVerificationEmailRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type VerifyEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"` // из ссылки в письме
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyEmailRequest) Reset() {
+	*x = VerifyEmailRequest{}
+	mi := &file_account_verification_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEmailRequest) ProtoMessage() {}
+
+func (x *VerifyEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_verification_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEmailRequest.ProtoReflect.Descriptor instead.
+func (*VerifyEmailRequest) Descriptor() ([]byte, []int) {
+	return file_account_verification_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VerifyEmailRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+// Код отправляется на номер из профиля текущего пользователя
+type RequestPhoneOTPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestPhoneOTPResponse) Reset() {
+	*x = RequestPhoneOTPResponse{}
+	mi := &file_account_verification_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestPhoneOTPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestPhoneOTPResponse) ProtoMessage() {}
+
+func (x *RequestPhoneOTPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_verification_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestPhoneOTPResponse.ProtoReflect.Descriptor instead.
+func (*RequestPhoneOTPResponse) Descriptor() ([]byte, []int) {
+	return file_account_verification_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RequestPhoneOTPResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type VerifyPhoneRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyPhoneRequest) Reset() {
+	*x = VerifyPhoneRequest{}
+	mi := &file_account_verification_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyPhoneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyPhoneRequest) ProtoMessage() {}
+
+func (x *VerifyPhoneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_verification_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyPhoneRequest.ProtoReflect.Descriptor instead.
+func (*VerifyPhoneRequest) Descriptor() ([]byte, []int) {
+	return file_account_verification_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyPhoneRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+var File_account_verification_proto protoreflect.FileDescriptor
+
+const file_account_verification_proto_rawDesc = "" +
+	"\n" +
+	"\x1aaccount/verification.proto\x12\fuser_profile\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"4\n" +
+	"\x1cSendVerificationEmailRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\"*\n" +
+	"\x12VerifyEmailRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"T\n" +
+	"\x17RequestPhoneOTPResponse\x129\n" +
+	"\n" +
+	"expires_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"(\n" +
+	"\x12VerifyPhoneRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code2\xd6\x02\n" +
+	"\x13VerificationService\x12[\n" +
+	"\x15SendVerificationEmail\x12*.user_profile.SendVerificationEmailRequest\x1a\x16.google.protobuf.Empty\x12G\n" +
+	"\vVerifyEmail\x12 .user_profile.VerifyEmailRequest\x1a\x16.google.protobuf.Empty\x12P\n" +
+	"\x0fRequestPhoneOTP\x12\x16.google.protobuf.Empty\x1a%.user_profile.RequestPhoneOTPResponse\x12G\n" +
+	"\vVerifyPhone\x12 .user_profile.VerifyPhoneRequest\x1a\x16.google.protobuf.EmptyB;Z9github.com/AronditFire/User-Service/gen/account;accountv1b\x06proto3"
+
+var (
+	file_account_verification_proto_rawDescOnce sync.Once
+	file_account_verification_proto_rawDescData []byte
+)
+
+func file_account_verification_proto_rawDescGZIP() []byte {
+	file_account_verification_proto_rawDescOnce.Do(func() {
+		file_account_verification_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_account_verification_proto_rawDesc), len(file_account_verification_proto_rawDesc)))
+	})
+	return file_account_verification_proto_rawDescData
+}
+
+var file_account_verification_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_account_verification_proto_goTypes = []any{
+	(*SendVerificationEmailRequest)(nil), // 0: user_profile.SendVerificationEmailRequest
+	(*VerifyEmailRequest)(nil),           // 1: user_profile.VerifyEmailRequest
+	(*RequestPhoneOTPResponse)(nil),      // 2: user_profile.RequestPhoneOTPResponse
+	(*VerifyPhoneRequest)(nil),           // 3: user_profile.VerifyPhoneRequest
+	(*timestamppb.Timestamp)(nil),        // 4: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                // 5: google.protobuf.Empty
+}
+var file_account_verification_proto_depIdxs = []int32{
+	4, // 0: user_profile.RequestPhoneOTPResponse.expires_at:type_name -> google.protobuf.Timestamp
+	0, // 1: user_profile.VerificationService.SendVerificationEmail:input_type -> user_profile.SendVerificationEmailRequest
+	1, // 2: user_profile.VerificationService.VerifyEmail:input_type -> user_profile.VerifyEmailRequest
+	5, // 3: user_profile.VerificationService.RequestPhoneOTP:input_type -> google.protobuf.Empty
+	3, // 4: user_profile.VerificationService.VerifyPhone:input_type -> user_profile.VerifyPhoneRequest
+	5, // 5: user_profile.VerificationService.SendVerificationEmail:output_type -> google.protobuf.Empty
+	5, // 6: user_profile.VerificationService.VerifyEmail:output_type -> google.protobuf.Empty
+	2, // 7: user_profile.VerificationService.RequestPhoneOTP:output_type -> user_profile.RequestPhoneOTPResponse
+	5, // 8: user_profile.VerificationService.VerifyPhone:output_type -> google.protobuf.Empty
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_account_verification_proto_init() }
+func file_account_verification_proto_init() {
+	if File_account_verification_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_verification_proto_rawDesc), len(file_account_verification_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_account_verification_proto_goTypes,
+		DependencyIndexes: file_account_verification_proto_depIdxs,
+		MessageInfos:      file_account_verification_proto_msgTypes,
+	}.Build()
+	File_account_verification_proto = out.File
+	file_account_verification_proto_goTypes = nil
+	file_account_verification_proto_depIdxs = nil
+}
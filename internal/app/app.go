@@ -1,9 +1,26 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	grpcapp "github.com/AronditFire/User-Service/internal/app/grpc"
+	"github.com/AronditFire/User-Service/internal/config"
+	authgrpc "github.com/AronditFire/User-Service/internal/grpc/auth"
+	authhttp "github.com/AronditFire/User-Service/internal/http/auth"
+	"github.com/AronditFire/User-Service/internal/lib/breach"
+	"github.com/AronditFire/User-Service/internal/lib/clientip"
+	"github.com/AronditFire/User-Service/internal/lib/encrypt"
+	"github.com/AronditFire/User-Service/internal/lib/jwt"
+	"github.com/AronditFire/User-Service/internal/lib/mailer"
+	"github.com/AronditFire/User-Service/internal/lib/passhash"
+	"github.com/AronditFire/User-Service/internal/lib/passpolicy"
+	"github.com/AronditFire/User-Service/internal/lib/ratelimit"
+	"github.com/AronditFire/User-Service/internal/lib/sms"
+	"github.com/AronditFire/User-Service/internal/lib/webauthn"
 	"github.com/AronditFire/User-Service/internal/services/auth"
+	"github.com/AronditFire/User-Service/internal/services/revocation"
 	uprofile "github.com/AronditFire/User-Service/internal/services/userProfile"
+	"github.com/AronditFire/User-Service/internal/services/verification"
 	repo "github.com/AronditFire/User-Service/internal/storage/postgres/auth"
 	"log/slog"
 	"net/http"
@@ -15,25 +32,256 @@ const DEFAULT_ROLE = "buyer"
 type App struct {
 	GRPCServer     *grpcapp.App
 	GINHTTPGateway *http.Server
+	Denylist       *revocation.Denylist // синхронизацию запускает main через Run
 }
 
-func New(
-	log *slog.Logger,
-	grpcPort int,
-	postgresDSN string,
-	accessTTL time.Duration,
-	refreshTTL time.Duration,
-	tokenSecret string,
-) *App {
-	storage, err := repo.New(postgresDSN)
+func New(log *slog.Logger, cfg *config.Config) *App {
+	storage, err := repo.New(cfg.PostgresDSN)
 	if err != nil {
 		panic(err)
 	}
 
-	authService := auth.New(log, storage, storage, storage, storage, storage, accessTTL, refreshTTL, tokenSecret, DEFAULT_ROLE)
-	profileService := uprofile.New(log, storage, storage)
+	keys, err := jwt.NewKeySet(jwtKeySpecs(cfg.JWT), cfg.JWT.ActiveKeyID, cfg.JWTSecret, jwt.Options{
+		Issuer:     cfg.JWT.Issuer,
+		Audience:   cfg.JWT.Audience,
+		Leeway:     cfg.JWT.Leeway,
+		Algorithms: cfg.JWT.Algorithms,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	clientIP, err := clientip.New(cfg.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+
+	// без MFA ключ шифрования не нужен; но пользователей с включённой 2FA нельзя оставить без второго фактора
+	var encryptor *encrypt.Encryptor
+	if cfg.MFA.Enabled {
+		encryptor, err = encrypt.New(cfg.MFA.EncryptionKey)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		enrolled, err := storage.ConfirmedTOTPCount(context.Background())
+		if err != nil {
+			panic(err)
+		}
+		if enrolled > 0 {
+			panic(fmt.Sprintf("mfa is disabled, but %d users have two-factor authentication enabled", enrolled))
+		}
+	}
+
+	var breached passpolicy.BreachCorpus
+	if cfg.PasswordPolicy.BreachedFile != "" {
+		breached, err = breach.Open(cfg.PasswordPolicy.BreachedFile)
+		if err != nil {
+			panic(err)
+		}
+	}
+	var maxPasswordBytes int
+	if cfg.PasswordHash.Algorithm == passhash.Bcrypt {
+		maxPasswordBytes = passhash.BcryptMaxPasswordBytes
+	}
+	policy, err := passpolicy.New(passpolicy.Options{
+		MinLength:          cfg.PasswordPolicy.MinLength,
+		MaxLength:          cfg.PasswordPolicy.MaxLength,
+		MaxBytes:           maxPasswordBytes,
+		RequireUpper:       cfg.PasswordPolicy.RequireUpper,
+		RequireLower:       cfg.PasswordPolicy.RequireLower,
+		RequireDigit:       cfg.PasswordPolicy.RequireDigit,
+		RequireSymbol:      cfg.PasswordPolicy.RequireSymbol,
+		RejectPersonalInfo: cfg.PasswordPolicy.RejectPersonalInfo,
+		BlocklistFile:      cfg.PasswordPolicy.BlocklistFile,
+	}, breached)
+	if err != nil {
+		panic(err)
+	}
+
+	hasher, err := passhash.New(passhash.Options{
+		Algorithm: cfg.PasswordHash.Algorithm,
+		Argon2: passhash.Argon2Params{
+			Memory:      cfg.PasswordHash.Argon2Memory,
+			Iterations:  cfg.PasswordHash.Argon2Iterations,
+			Parallelism: cfg.PasswordHash.Argon2Parallelism,
+		},
+		BcryptCost: cfg.PasswordHash.BcryptCost,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	denylist := revocation.New(log, storage, cfg.Revocation.SyncInterval)
+	// первая загрузка до старта серверов, иначе до первого тика все токены отклонялись бы
+	if err := denylist.Sync(context.Background()); err != nil {
+		panic(err)
+	}
+	mail := newMailer(log, cfg.Mail)
+	smsSender := newSMSSender(log, cfg.SMS)
+	verificationService := verification.New(log, storage, storage, mail, storage, smsSender,
+		verification.PhoneOptions{
+			CodeTTL:        cfg.PhoneVerification.CodeTTL,
+			MaxAttempts:    cfg.PhoneVerification.MaxAttempts,
+			ResendInterval: cfg.PhoneVerification.ResendInterval,
+		}, cfg.RefreshTokenSecret, cfg.EmailVerification.TokenTTL, cfg.EmailVerification.LinkURL)
 
-	grpcApp := grpcapp.New(log, authService, profileService, grpcPort, tokenSecret)
+	authService := auth.New(log, auth.Deps{
+		UserSaver:    storage,
+		UserProvider: storage,
+		RoleProvider: storage,
+		TokenRepo:    storage,
+		Revoker:      denylist,
+		MFARepo:      storage,
+		Encryptor:    encryptor,
+		Passkeys:     storage,
+		RelyingParty: &webauthn.RelyingParty{
+			ID:                      cfg.WebAuthn.RPID,
+			Name:                    cfg.WebAuthn.RPName,
+			Origins:                 cfg.WebAuthn.Origins,
+			Timeout:                 cfg.WebAuthn.Timeout,
+			RequireUserVerification: cfg.WebAuthn.RequireUserVerification,
+		},
+		Verifier:  verificationService,
+		Passwords: storage,
+		Mailer:    mail,
+		SMS:       smsSender,
+		Policy:    policy,
+		Hasher:    hasher,
+		Throttle:  storage,
+		OAuthRepo: storage,
+		Keys:      keys,
+	}, auth.Options{
+		AccessTTL:            cfg.AccessTTL,
+		RefreshTTL:           cfg.RefreshTTL,
+		TokenSecret:          cfg.RefreshTokenSecret,
+		DefaultRole:          DEFAULT_ROLE,
+		RequireVerifiedEmail: cfg.EmailVerification.RequireForLogin,
+		MFA: auth.MFAOptions{
+			Enabled:       cfg.MFA.Enabled,
+			Issuer:        cfg.MFA.Issuer,
+			ChallengeTTL:  cfg.MFA.ChallengeTTL,
+			MaxAttempts:   cfg.MFA.MaxAttempts,
+			RecoveryCodes: cfg.MFA.RecoveryCodes,
+		},
+		PasswordReset: auth.PasswordResetOptions{
+			TokenTTL: cfg.PasswordReset.TokenTTL,
+			LinkURL:  cfg.PasswordReset.LinkURL,
+		},
+		Lockout: auth.LockoutOptions{
+			FreeAttempts:         cfg.LoginLockout.FreeAttempts,
+			IPFreeAttempts:       cfg.LoginLockout.IPFreeAttempts,
+			BaseDelay:            cfg.LoginLockout.BaseDelay,
+			MaxDelay:             cfg.LoginLockout.MaxDelay,
+			AccountLockThreshold: cfg.LoginLockout.AccountLockThreshold,
+			IPLockThreshold:      cfg.LoginLockout.IPLockThreshold,
+			LockDuration:         cfg.LoginLockout.LockDuration,
+			ResetAfter:           cfg.LoginLockout.ResetAfter,
+		},
+		OAuth: auth.OAuthOptions{
+			ConsentURL: cfg.OAuth.ConsentURL,
+			RequestTTL: cfg.OAuth.RequestTTL,
+			CodeTTL:    cfg.OAuth.CodeTTL,
+		},
+	})
+	profileService := uprofile.New(log, storage, storage, denylist)
 
-	return &App{GRPCServer: grpcApp}
+	grpcApp := grpcapp.New(log, authgrpc.Services{
+		Auth:         authService,
+		Profile:      profileService,
+		Sessions:     authService,
+		MFA:          authService,
+		Passkeys:     authService,
+		Verification: verificationService,
+		Passwords:    authService,
+		Lockout:      authService,
+		OAuthClients: authService,
+		Keys:         keys,
+		Introspector: authService,
+	}, denylist, keys, newRateLimiter(cfg.RateLimit, storage), rateLimits(cfg.RateLimit), clientIP, cfg.GRPC.Port)
+
+	httpGateway := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.HTTP.Port),
+		Handler:           authhttp.NewRouter(log, keys, authService, authService, clientIP, cfg.JWT.Issuer),
+		ReadHeaderTimeout: cfg.HTTP.Timeout,
+		WriteTimeout:      cfg.HTTP.Timeout,
+	}
+
+	return &App{GRPCServer: grpcApp, GINHTTPGateway: httpGateway, Denylist: denylist}
+}
+
+// строгие лимиты для методов, которыми перебирают пароли и коды или шлют письма и SMS;
+// методы из конфига их переопределяют
+var defaultRateLimits = map[string]ratelimit.Limit{
+	"/user_profile.UserService/Register":                      {Rate: 0.05, Burst: 3},
+	"/user_profile.UserService/Login":                         {Rate: 0.2, Burst: 10},
+	"/user_profile.MFAService/VerifyMFA":                      {Rate: 0.2, Burst: 5},
+	"/user_profile.PasskeyService/FinishPasskeyLogin":         {Rate: 0.2, Burst: 10},
+	"/user_profile.VerificationService/SendVerificationEmail": {Rate: 0.02, Burst: 3},
+	"/user_profile.VerificationService/RequestPhoneOTP":       {Rate: 0.02, Burst: 3},
+	"/user_profile.VerificationService/VerifyPhone":           {Rate: 0.1, Burst: 5},
+	"/user_profile.PasswordService/RequestPasswordReset":      {Rate: 0.02, Burst: 3},
+	"/user_profile.PasswordService/ResetPassword":             {Rate: 0.1, Burst: 5},
+	"/user_profile.PasswordService/ChangePassword":            {Rate: 0.1, Burst: 5},
+}
+
+func rateLimits(cfg config.RateLimitConfig) authgrpc.RateLimits {
+	limits := authgrpc.RateLimits{
+		Default: ratelimit.Limit{Rate: cfg.Default.Rate, Burst: cfg.Default.Burst},
+		Methods: make(map[string]ratelimit.Limit, len(defaultRateLimits)+len(cfg.Methods)),
+	}
+	for method, limit := range defaultRateLimits {
+		limits.Methods[method] = limit
+	}
+	for method, rule := range cfg.Methods {
+		limits.Methods[method] = ratelimit.Limit{Rate: rule.Rate, Burst: rule.Burst}
+	}
+	return limits
+}
+
+func newRateLimiter(cfg config.RateLimitConfig, store ratelimit.Store) ratelimit.Limiter {
+	switch cfg.Backend {
+	case "memory":
+		return ratelimit.NewMemory()
+	case "postgres":
+		// час с запасом больше времени наполнения bucket'ов из defaultRateLimits
+		return ratelimit.NewShared(store, time.Hour)
+	}
+	panic("unknown rate limit backend: " + cfg.Backend)
+}
+
+func jwtKeySpecs(cfg config.JWTConfig) []jwt.KeySpec {
+	specs := make([]jwt.KeySpec, len(cfg.Keys))
+	for i, key := range cfg.Keys {
+		specs[i] = jwt.KeySpec{
+			ID:             key.ID,
+			Algorithm:      key.Algorithm,
+			PrivateKeyFile: key.PrivateKeyFile,
+			PublicKeyFile:  key.PublicKeyFile,
+			Retired:        key.Retired,
+		}
+	}
+	return specs
+}
+
+func newMailer(log *slog.Logger, cfg config.MailConfig) mailer.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case "file":
+		return mailer.NewFile(cfg.Dir, cfg.From)
+	case "log":
+		return mailer.NewLog(log)
+	}
+	panic("unknown mail driver: " + cfg.Driver)
+}
+
+func newSMSSender(log *slog.Logger, cfg config.SMSConfig) sms.SMSSender {
+	switch cfg.Driver {
+	case "console":
+		return sms.NewConsole(log)
+	case "file":
+		return sms.NewFile(cfg.File)
+	}
+	panic("unknown sms driver: " + cfg.Driver)
 }
@@ -3,6 +3,9 @@ package grpcapp
 import (
 	"fmt"
 	"github.com/AronditFire/User-Service/internal/grpc/auth"
+	"github.com/AronditFire/User-Service/internal/lib/clientip"
+	"github.com/AronditFire/User-Service/internal/lib/jwt"
+	"github.com/AronditFire/User-Service/internal/lib/ratelimit"
 	"google.golang.org/grpc"
 	"log/slog"
 	"net"
@@ -12,24 +15,33 @@ type App struct {
 	log        *slog.Logger
 	GRPCServer *grpc.Server
 	port       int
-	jwtSecret  string
 }
 
-func New(log *slog.Logger, auth authgrpc.Auth, prof authgrpc.UserProfile, port int, jwtSecret string) *App {
+func New(
+	log *slog.Logger,
+	services authgrpc.Services,
+	denylist authgrpc.RevocationChecker,
+	keys *jwt.KeySet,
+	limiter ratelimit.Limiter,
+	limits authgrpc.RateLimits,
+	clientIP *clientip.Resolver,
+	port int,
+) *App {
 
 	gRPCServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
-			authgrpc.UnaryAuthInterceptor(jwtSecret),
+			authgrpc.UnaryClientIPInterceptor(clientIP),
+			authgrpc.UnaryAuthInterceptor(keys, denylist),
+			authgrpc.UnaryRateLimitInterceptor(log, limiter, limits),
 		),
 	)
 
-	authgrpc.RegisterUserService(gRPCServer, auth, prof)
+	authgrpc.RegisterUserService(gRPCServer, log, services)
 
 	return &App{
 		log:        log,
 		GRPCServer: gRPCServer,
 		port:       port,
-		jwtSecret:  jwtSecret,
 	}
 }
 
@@ -6,14 +6,31 @@ import (
 )
 
 type Config struct {
-	Env          string        `yaml:"env" env-default:"local"`
-	AccessTTL    time.Duration `yaml:"access_ttl" env-required:"true"`
-	RefreshTTL   time.Duration `yaml:"refresh_ttl" env-required:"true"`
-	PostgresDSN  string        `yaml:"postgres_dsn" env-required:"true"`
-	JWTSecret    string        `yaml:"jwt_secret" env-required:"true"`
-	LogLevel     string        `yaml:"log_level" env-required:"true"`
-	MigrationURL string        `yaml:"migration_url" env-required:"true"`
-	GRPC         GRPCConfig    `yaml:"grpc" env-required:"true"`
+	Env                string                  `yaml:"env" env-default:"local"`
+	AccessTTL          time.Duration           `yaml:"access_ttl" env-required:"true"`
+	RefreshTTL         time.Duration           `yaml:"refresh_ttl" env-required:"true"`
+	PostgresDSN        string                  `yaml:"postgres_dsn" env-required:"true"`
+	JWTSecret          string                  `yaml:"jwt_secret"`                               // legacy HS256, пустой если используются только ключи из JWT
+	RefreshTokenSecret string                  `yaml:"refresh_token_secret" env-required:"true"` // ключ HMAC для refresh токенов
+	LogLevel           string                  `yaml:"log_level" env-required:"true"`
+	MigrationURL       string                  `yaml:"migration_url" env-required:"true"`
+	TrustedProxies     []string                `yaml:"trusted_proxies"` // адреса и CIDR прокси, от которых принимается X-Forwarded-For
+	GRPC               GRPCConfig              `yaml:"grpc" env-required:"true"`
+	HTTP               HTTPConfig              `yaml:"http"`
+	Revocation         RevocationConfig        `yaml:"revocation"`
+	JWT                JWTConfig               `yaml:"jwt"`
+	MFA                MFAConfig               `yaml:"mfa"`
+	WebAuthn           WebAuthnConfig          `yaml:"webauthn"`
+	Mail               MailConfig              `yaml:"mail"`
+	EmailVerification  EmailVerificationConfig `yaml:"email_verification"`
+	SMS                SMSConfig               `yaml:"sms"`
+	PhoneVerification  PhoneVerificationConfig `yaml:"phone_verification"`
+	PasswordReset      PasswordResetConfig     `yaml:"password_reset"`
+	PasswordPolicy     PasswordPolicyConfig    `yaml:"password_policy"`
+	PasswordHash       PasswordHashConfig      `yaml:"password_hash"`
+	LoginLockout       LoginLockoutConfig      `yaml:"login_lockout"`
+	RateLimit          RateLimitConfig         `yaml:"rate_limit"`
+	OAuth              OAuthConfig             `yaml:"oauth"`
 }
 
 type GRPCConfig struct {
@@ -21,6 +38,116 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout" env-required:"true"`
 }
 
+type HTTPConfig struct {
+	Port    int           `yaml:"port" env-default:"8080"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type RevocationConfig struct {
+	SyncInterval time.Duration `yaml:"sync_interval" env-default:"30s"` // как часто перечитывать отозванные access токены
+}
+
+type MFAConfig struct {
+	Enabled       bool          `yaml:"enabled" env-default:"true"` // выключить можно, только пока ни у кого не включена 2FA
+	EncryptionKey string        `yaml:"encryption_key"`             // base64, 32 байта (AES-256-GCM) для TOTP секретов; обязателен при enabled
+	Issuer        string        `yaml:"issuer" env-default:"User Service"`
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	MaxAttempts   int           `yaml:"max_attempts" env-default:"5"`
+	RecoveryCodes int           `yaml:"recovery_codes" env-default:"10"`
+}
+
+type WebAuthnConfig struct {
+	RPID                    string        `yaml:"rp_id" env-default:"localhost"` // домен сайта, к которому привязаны passkeys
+	RPName                  string        `yaml:"rp_name" env-default:"User Service"`
+	Origins                 []string      `yaml:"origins" env-default:"http://localhost:3000"`
+	Timeout                 time.Duration `yaml:"timeout" env-default:"2m"`
+	RequireUserVerification bool          `yaml:"require_user_verification" env-default:"true"`
+}
+
+type MailConfig struct {
+	Driver       string `yaml:"driver" env-default:"log"` // smtp | file | log
+	From         string `yaml:"from" env-default:"no-reply@localhost"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port" env-default:"587"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	Dir          string `yaml:"dir" env-default:"./mail"` // для driver: file
+}
+
+type EmailVerificationConfig struct {
+	TokenTTL        time.Duration `yaml:"token_ttl" env-default:"24h"`
+	LinkURL         string        `yaml:"link_url" env-default:"http://localhost:3000/verify-email"` // страница фронтенда, токен в параметре token
+	RequireForLogin bool          `yaml:"require_for_login" env-default:"false"`
+}
+
+type SMSConfig struct {
+	Driver string `yaml:"driver" env-default:"console"` // console | file
+	File   string `yaml:"file" env-default:"./sms.log"` // для driver: file
+}
+
+type PhoneVerificationConfig struct {
+	CodeTTL        time.Duration `yaml:"code_ttl" env-default:"5m"`
+	MaxAttempts    int           `yaml:"max_attempts" env-default:"5"`
+	ResendInterval time.Duration `yaml:"resend_interval" env-default:"1m"`
+}
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	LinkURL  string        `yaml:"link_url" env-default:"http://localhost:3000/reset-password"` // страница фронтенда, токен в параметре token
+}
+
+type PasswordPolicyConfig struct {
+	MinLength          int    `yaml:"min_length" env-default:"8"`
+	MaxLength          int    `yaml:"max_length" env-default:"128"` // в символах; при bcrypt пароль не длиннее 72 байт
+	RequireUpper       bool   `yaml:"require_upper" env-default:"false"`
+	RequireLower       bool   `yaml:"require_lower" env-default:"false"`
+	RequireDigit       bool   `yaml:"require_digit" env-default:"false"`
+	RequireSymbol      bool   `yaml:"require_symbol" env-default:"false"`
+	RejectPersonalInfo bool   `yaml:"reject_personal_info" env-default:"true"` // username, email, ФИО
+	BlocklistFile      string `yaml:"blocklist_file"`                          // частые пароли, по одному на строку
+	BreachedFile       string `yaml:"breached_file"`                           // файл HIBP SHA-1 или фильтр из cmd/breachfilter
+}
+
+// PasswordHashConfig задает алгоритм новых хешей, хеши с другими параметрами обновляются при входе
+type PasswordHashConfig struct {
+	Algorithm         string `yaml:"algorithm" env-default:"argon2id"`  // argon2id | bcrypt
+	Argon2Memory      uint32 `yaml:"argon2_memory" env-default:"65536"` // KiB
+	Argon2Iterations  uint32 `yaml:"argon2_iterations" env-default:"3"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism" env-default:"2"`
+	BcryptCost        int    `yaml:"bcrypt_cost" env-default:"10"`
+}
+
+// LoginLockoutConfig ограничивает подбор пароля: задержка растет вдвое с каждой неудачей после free_attempts,
+// после порога вход блокируется на lock_duration (0 - не блокировать)
+type LoginLockoutConfig struct {
+	FreeAttempts         int           `yaml:"free_attempts" env-default:"3"`
+	IPFreeAttempts       int           `yaml:"ip_free_attempts" env-default:"20"` // за одним NAT много пользователей
+	BaseDelay            time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay             time.Duration `yaml:"max_delay" env-default:"5m"`
+	AccountLockThreshold int           `yaml:"account_lock_threshold" env-default:"10"`
+	IPLockThreshold      int           `yaml:"ip_lock_threshold" env-default:"100"`
+	LockDuration         time.Duration `yaml:"lock_duration" env-default:"30m"`
+	ResetAfter           time.Duration `yaml:"reset_after" env-default:"24h"`
+}
+
+type RateLimitConfig struct {
+	Backend string                   `yaml:"backend" env-default:"memory"` // memory | postgres (общий для всех реплик)
+	Default RateLimitRule            `yaml:"default"`
+	Methods map[string]RateLimitRule `yaml:"methods"` // ключ - полное имя метода, /user_profile.UserService/Login
+}
+
+// RateLimitRule: rate запросов в секунду на клиента, burst подряд; rate 0 - без ограничений
+type RateLimitRule struct {
+	Rate  float64 `yaml:"rate" env-default:"10"`
+	Burst int     `yaml:"burst" env-default:"20"`
+}
+
+type OAuthConfig struct {
+	ConsentURL string        `yaml:"consent_url" env-default:"http://localhost:3000/oauth/consent"` // страница фронтенда, id запроса в параметре request_id
+	RequestTTL time.Duration `yaml:"request_ttl" env-default:"10m"`
+	CodeTTL    time.Duration `yaml:"code_ttl" env-default:"1m"`
+}
+
 func MustLoad() *Config {
 	var cfg Config
 	// TODO: change to .env file
@@ -31,3 +158,20 @@ func MustLoad() *Config {
 
 	return &cfg
 }
+
+type JWTConfig struct {
+	Issuer      string         `yaml:"issuer"` // публичный URL сервиса, iss токенов и метаданных сервера авторизации
+	Audience    []string       `yaml:"audience"`
+	Leeway      time.Duration  `yaml:"leeway" env-default:"30s"`
+	Algorithms  []string       `yaml:"algorithms"` // если пусто - алгоритмы настроенных ключей
+	ActiveKeyID string         `yaml:"active_kid"`
+	Keys        []JWTKeyConfig `yaml:"keys"`
+}
+
+type JWTKeyConfig struct {
+	ID             string `yaml:"kid"`
+	Algorithm      string `yaml:"alg"` // RS256, ES256, EdDSA ...
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"` // только для проверки, если приватного ключа нет
+	Retired        bool   `yaml:"retired"`
+}
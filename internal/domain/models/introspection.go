@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TokenIntrospection result of RFC 7662 token introspection, other fields are empty if not Active
+type TokenIntrospection struct {
+	Active    bool
+	UserID    int64
+	Subject   string
+	Role      string
+	SessionID string
+	TokenID   string
+	Issuer    string
+	Audience  []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Scope     string // space-separated, empty for first-party tokens
+	ClientID  string
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// LoginFailures is the failed login counter of an account or a source IP
+type LoginFailures struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// LoginBackoff: after FreeAttempts failures the next attempt waits BaseDelay, then twice as long up to MaxDelay;
+// a counter older than ResetAfter starts again
+type LoginBackoff struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	ResetAfter   time.Duration
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// TOTP is the user's authenticator, Secret is encrypted
+type TOTP struct {
+	UserID       int64
+	Secret       []byte
+	ConfirmedAt  *time.Time // 2FA is enabled only after confirmation
+	LastUsedStep int64
+}
+
+// TOTPEnrollment is returned to the user once, to be shown as QR code
+type TOTPEnrollment struct {
+	Secret        string // base32
+	URI           string // otpauth://
+	RecoveryCodes []string
+}
+
+// MFAChallenge is the pending second login step
+type MFAChallenge struct {
+	UserID    int64
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// RecoveryCode is an unused recovery code, Hash is bcrypt
+type RecoveryCode struct {
+	ID   int64
+	Hash []byte
+}
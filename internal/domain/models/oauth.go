@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// OAuthClient is an application registered to obtain tokens through the OAuth flows
+type OAuthClient struct {
+	ID           string
+	Name         string
+	RedirectURIs []string
+	Scopes       []string // scopes the client may request
+	GrantTypes   []string
+	SecretHash   string // empty for public clients, they authenticate with PKCE only
+	CreatedAt    time.Time
+}
+
+// AuthorizationRequest is a validated /authorize request waiting for the user's consent
+type AuthorizationRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	CodeChallenge string // S256
+	ExpiresAt     time.Time
+}
+
+// AuthorizationCode is issued after consent and exchanged once for tokens
+type AuthorizationCode struct {
+	ClientID      string
+	UserID        int64
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	FamilyID      string // session created by the exchange, revoked if the code is presented again
+}
+
+// OAuthGrant is set on refresh tokens issued to OAuth clients
+type OAuthGrant struct {
+	ClientID string
+	Scopes   []string
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Passkey is a WebAuthn credential registered by the user
+type Passkey struct {
+	ID         []byte
+	UserID     int64
+	Name       string
+	PublicKey  []byte // COSE_Key
+	SignCount  uint32
+	AAGUID     []byte
+	Format     string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// PasskeyCreationOptions are passed by the client to navigator.credentials.create()
+type PasskeyCreationOptions struct {
+	Challenge          []byte
+	RPID               string
+	RPName             string
+	UserHandle         []byte
+	UserName           string
+	UserDisplayName    string
+	Algorithms         []int64 // COSE
+	ExcludeCredentials [][]byte
+	Timeout            time.Duration
+	UserVerification   string
+}
+
+// PasskeyRequestOptions are passed by the client to navigator.credentials.get()
+type PasskeyRequestOptions struct {
+	Challenge        []byte
+	RPID             string
+	AllowCredentials [][]byte // пусто - discoverable credential
+	Timeout          time.Duration
+	UserVerification string
+}
+
+// PasskeyAssertion is the result of navigator.credentials.get()
+type PasskeyAssertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SessionInfo describes the client the session was started from
+type SessionInfo struct {
+	DeviceName string
+	UserAgent  string
+	IP         string
+}
+
+// Session is an active refresh token family
+type Session struct {
+	ID         string
+	UserID     int64
+	Info       SessionInfo
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
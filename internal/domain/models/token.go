@@ -3,8 +3,32 @@ package models
 import "time"
 
 type RefreshTokenClaims struct {
-	Token     string
+	TokenHash string // HMAC-SHA256 of the token, raw value is never stored
 	UserID    int64
+	FamilyID  string
 	IssuedAt  time.Time
 	ExpiresAt time.Time
+	UsedAt    *time.Time // set when the token has been rotated
+	RevokedAt *time.Time // set when the whole family has been revoked
+	Grant     OAuthGrant // empty for first-party sessions
+}
+
+// AccessToken identifies an issued access token by its jti
+type AccessToken struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+type RevokedAccessToken struct {
+	AccessToken
+	RevokedAt time.Time
+}
+
+// PhoneOTP is the pending phone verification code
+type PhoneOTP struct {
+	UserID      int64
+	PhoneNumber string
+	CodeHash    string
+	Attempts    int
+	ExpiresAt   time.Time
 }
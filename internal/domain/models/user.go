@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	ID          int64
 	Username    string
@@ -7,6 +9,11 @@ type User struct {
 	FIO         string
 	PhoneNumber string
 	PassHash    []byte
+	BlockedAt   *time.Time
+	// EmailVerifiedAt is set when the user followed the link from the verification email
+	EmailVerifiedAt *time.Time
+	// PhoneVerifiedAt is set when the user entered the code from SMS
+	PhoneVerifiedAt *time.Time
 }
 
 type UserWithRole struct {
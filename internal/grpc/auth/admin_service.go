@@ -0,0 +1,62 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	uprofile "github.com/AronditFire/User-Service/internal/services/userProfile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Lockout снимает блокировку входа после неудачных попыток
+type Lockout interface {
+	UnlockAccount(ctx context.Context, userID int64) error
+}
+
+func (s *ServerAPI) BlockUser(ctx context.Context, req *accountv1.AdminUserRequest) (*emptypb.Empty, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be greater than 0")
+	}
+
+	if err := s.uProf.BlockUser(ctx, req.GetUserId()); err != nil {
+		if errors.Is(err, uprofile.ErrInvalidCredentials) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) UnblockUser(ctx context.Context, req *accountv1.AdminUserRequest) (*emptypb.Empty, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be greater than 0")
+	}
+
+	if err := s.uProf.UnblockUser(ctx, req.GetUserId()); err != nil {
+		if errors.Is(err, uprofile.ErrInvalidCredentials) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) UnlockAccount(ctx context.Context, req *accountv1.AdminUserRequest) (*emptypb.Empty, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be greater than 0")
+	}
+
+	if err := s.lockout.UnlockAccount(ctx, req.GetUserId()); err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
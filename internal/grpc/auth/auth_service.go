@@ -2,17 +2,27 @@ package authgrpc
 
 import (
 	"context"
+	"errors"
 	uservicev1 "github.com/AronditFire/UService-ProtobufNew/gen/user-service"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/services/auth"
 	val "github.com/AronditFire/User-Service/internal/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"math"
+	"strconv"
+	"time"
 )
 
 type Auth interface {
-	Login(ctx context.Context, username string, password string) (string, string, error)
-	RegisterUser(ctx context.Context, username string, email string, FIO string, phoneNumber string, password string) (int64, error)
-	Refresh(ctx context.Context, refreshToken string) (string, string, error) // new access, new refresh, error
+	Login(ctx context.Context, login string, password string, info models.SessionInfo) (string, string, error)
+	RegisterUser(ctx context.Context, username string, email string, FIO string, phoneNumber string, password string) error
+	Refresh(ctx context.Context, refreshToken string, info models.SessionInfo) (string, string, error) // new access, new refresh, error
 	Logout(ctx context.Context, refreshToken string) error
 }
 
@@ -20,24 +30,48 @@ func (s *ServerAPI) Register(ctx context.Context, req *uservicev1.RegisterReques
 	if err := ValidateRegister(req); err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error()) // TODO: validate errors
 	}
-	userID, err := s.auth.RegisterUser(ctx, req.GetUsername(), req.GetEmail(), req.GetFIO(), req.GetPhoneNumber(), req.GetPassword())
+	err := s.auth.RegisterUser(ctx, req.GetUsername(), req.GetEmail(), req.GetFIO(), req.GetPhoneNumber(), req.GetPassword())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return nil, passwordPolicyError(policyErr, "password")
+		}
+		return nil, s.internalError(err)
 	}
-	return &uservicev1.RegisterResponse{UserId: userID}, nil
+	// user_id не возвращается: ответ одинаков, даже если username, email или телефон уже заняты
+	return &uservicev1.RegisterResponse{}, nil
 }
 
+// Login принимает в поле username любой идентификатор: username, email или телефон
 func (s *ServerAPI) Login(ctx context.Context, req *uservicev1.LoginRequest) (*uservicev1.LoginResponse, error) {
-	if err := val.CheckUsername(req.GetUsername()); err != nil {
+	if _, _, err := val.ClassifyLogin(req.GetUsername()); err != nil {
 		return nil, err
 	}
 	if err := val.CheckPassword(req.GetPassword()); err != nil {
 		return nil, err
 	}
 
-	accessToken, refreshToken, err := s.auth.Login(ctx, req.GetUsername(), req.GetPassword())
+	accessToken, refreshToken, err := s.auth.Login(ctx, req.GetUsername(), req.GetPassword(), sessionInfo(ctx))
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error()) // TODO: maybe change the code
+		var mfaErr *auth.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return nil, mfaRequiredError(mfaErr)
+		}
+		var throttled *auth.LoginThrottledError
+		if errors.As(err, &throttled) {
+			return nil, loginThrottledError(ctx, throttled)
+		}
+		if errors.Is(err, auth.ErrUserBlocked) {
+			return nil, status.Error(codes.PermissionDenied, "user is blocked")
+		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+		// неверный логин и неверный пароль неотличимы для клиента
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "invalid login or password")
+		}
+		return nil, s.internalError(err)
 	}
 
 	return &uservicev1.LoginResponse{
@@ -54,9 +88,12 @@ func (s *ServerAPI) RefreshToken(ctx context.Context, req *uservicev1.RefreshReq
 		return nil, status.Error(codes.InvalidArgument, "refresh token is empty")
 	}
 
-	newAccessToken, newRefreshToken, err := s.auth.Refresh(ctx, req.GetRefreshToken())
+	newAccessToken, newRefreshToken, err := s.auth.Refresh(ctx, req.GetRefreshToken(), sessionInfo(ctx))
 	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, err.Error())
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired refresh token")
+		}
+		return nil, s.internalError(err)
 	}
 
 	return &uservicev1.RefreshResponse{Tokens: &uservicev1.Tokens{
@@ -65,14 +102,17 @@ func (s *ServerAPI) RefreshToken(ctx context.Context, req *uservicev1.RefreshReq
 	}}, nil
 }
 
-// Logout delete current refresh token from database
+// Logout revokes the session (token family) of the current refresh token
 func (s *ServerAPI) Logout(ctx context.Context, req *uservicev1.LogoutRequest) (*emptypb.Empty, error) {
 	if req.GetRefreshToken() == "" {
 		return nil, status.Error(codes.InvalidArgument, "refresh token is empty")
 	}
 
 	if err := s.auth.Logout(ctx, req.GetRefreshToken()); err != nil {
-		return nil, status.Error(codes.Internal, err.Error()) // TODO: maybe 2 error type: expired and internal error
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+		return nil, s.internalError(err)
 	}
 
 	return &emptypb.Empty{}, nil
@@ -88,7 +128,7 @@ func ValidateRegister(req *uservicev1.RegisterRequest) error {
 	if err := val.CheckEmail(req.GetEmail()); err != nil {
 		return err
 	}
-	if err := val.CheckPhoneNumber(req.GetPhoneNumber()); err != nil {
+	if err := val.CheckPhoneNumber(val.NormalizePhone(req.GetPhoneNumber())); err != nil {
 		return err
 	}
 	if err := val.CheckFIO(req.GetFIO()); err != nil {
@@ -96,3 +136,26 @@ func ValidateRegister(req *uservicev1.RegisterRequest) error {
 	}
 	return nil
 }
+
+// loginThrottledError возвращает ResourceExhausted при задержке между попытками и Unavailable при блокировке
+func loginThrottledError(ctx context.Context, err *auth.LoginThrottledError) error {
+	if err.Locked {
+		return retryAfterError(ctx, codes.Unavailable, "login is temporarily locked", err.RetryAfter)
+	}
+	return retryAfterError(ctx, codes.ResourceExhausted, "too many failed login attempts, try again later", err.RetryAfter)
+}
+
+// retryAfterError передает время до следующей попытки в RetryInfo и заголовком retry-after (секунды)
+func retryAfterError(ctx context.Context, code codes.Code, msg string, retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+
+	st := status.New(code, msg)
+	detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(seconds) * time.Second),
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,40 @@
+package authgrpc
+
+import (
+	"context"
+	"github.com/AronditFire/User-Service/internal/lib/clientip"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+)
+
+// UnaryClientIPInterceptor кладет в контекст адрес клиента. x-forwarded-for учитывается,
+// только если соединение пришло от доверенного прокси. Ставится первым в цепочке.
+func UnaryClientIPInterceptor(resolver *clientip.Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if ip := resolver.Resolve(peerAddr(ctx), metadata.ValueFromIncomingContext(ctx, "x-forwarded-for")); ip != "" {
+			ctx = context.WithValue(ctx, "client_ip", ip)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// clientIP адрес из UnaryClientIPInterceptor, без него - адрес пира
+func clientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value("client_ip").(string); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(peerAddr(ctx))
+	if err != nil {
+		return peerAddr(ctx)
+	}
+	return host
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package authgrpc
+
+import (
+	"context"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Introspector interface {
+	Introspect(ctx context.Context, accessToken string) (models.TokenIntrospection, error)
+}
+
+// IntrospectToken reports whether the access token is active (RFC 7662)
+func (s *ServerAPI) IntrospectToken(ctx context.Context, req *accountv1.IntrospectRequest) (*accountv1.IntrospectResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is empty")
+	}
+	// token_type_hint не учитывается: проверяется только access токен, а подсказка не должна менять ответ (RFC 7662, 2.1)
+
+	result, err := s.introspector.Introspect(ctx, req.GetToken())
+	if err != nil {
+		return nil, s.internalError(err)
+	}
+	if !result.Active {
+		return &accountv1.IntrospectResponse{Active: false}, nil
+	}
+
+	resp := &accountv1.IntrospectResponse{
+		Active:    true,
+		Sub:       result.Subject,
+		UserId:    result.UserID,
+		SessionId: result.SessionID,
+		Jti:       result.TokenID,
+		Iss:       result.Issuer,
+		Aud:       result.Audience,
+		Iat:       timestamppb.New(result.IssuedAt),
+		Exp:       timestamppb.New(result.ExpiresAt),
+		TokenType: "access_token",
+		Scope:     result.Scope,
+		ClientId:  result.ClientID,
+	}
+	if result.Role != "" { // у токенов client_credentials нет пользователя и роли
+		resp.Roles = []string{result.Role}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,34 @@
+package authgrpc
+
+import (
+	"context"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/lib/jwt"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type KeyProvider interface {
+	JWKS() jwt.JWKS
+}
+
+// GetJWKS returns public keys access tokens can be verified with
+func (s *ServerAPI) GetJWKS(_ context.Context, _ *emptypb.Empty) (*accountv1.JWKSResponse, error) {
+	set := s.keys.JWKS()
+
+	resp := &accountv1.JWKSResponse{Keys: make([]*accountv1.JWK, len(set.Keys))}
+	for i, key := range set.Keys {
+		resp.Keys[i] = &accountv1.JWK{
+			Kid: key.KeyID,
+			Kty: key.KeyType,
+			Alg: key.Algorithm,
+			Use: key.Use,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Curve,
+			X:   key.X,
+			Y:   key.Y,
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,154 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	uservicev1 "github.com/AronditFire/UService-ProtobufNew/gen/user-service"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	val "github.com/AronditFire/User-Service/internal/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"time"
+)
+
+type MFA interface {
+	EnrollTOTP(ctx context.Context, userID int64) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int64, code string) error
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+	VerifyMFA(ctx context.Context, mfaToken string, code string, info models.SessionInfo) (string, string, error) // access, refresh, error
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+}
+
+func (s *ServerAPI) EnrollTOTP(ctx context.Context, _ *emptypb.Empty) (*accountv1.EnrollTOTPResponse, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+
+	enrollment, err := s.mfa.EnrollTOTP(ctx, userID)
+	if err != nil {
+		return nil, s.mfaError(err)
+	}
+
+	return &accountv1.EnrollTOTPResponse{
+		Secret:        enrollment.Secret,
+		OtpauthUri:    enrollment.URI,
+		RecoveryCodes: enrollment.RecoveryCodes,
+	}, nil
+}
+
+func (s *ServerAPI) ConfirmTOTP(ctx context.Context, req *accountv1.TOTPCodeRequest) (*emptypb.Empty, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	if err := val.CheckMFACode(req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfa.ConfirmTOTP(ctx, userID, req.GetCode()); err != nil {
+		return nil, s.mfaError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) DisableTOTP(ctx context.Context, req *accountv1.TOTPCodeRequest) (*emptypb.Empty, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	if err := val.CheckMFACode(req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfa.DisableTOTP(ctx, userID, req.GetCode()); err != nil {
+		return nil, s.mfaError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) RegenerateRecoveryCodes(ctx context.Context, req *accountv1.TOTPCodeRequest) (*accountv1.RecoveryCodesResponse, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	if err := val.CheckMFACode(req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.mfa.RegenerateRecoveryCodes(ctx, userID, req.GetCode())
+	if err != nil {
+		return nil, s.mfaError(err)
+	}
+
+	return &accountv1.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// VerifyMFA is the second login step, mfa token comes from the Login error details
+func (s *ServerAPI) VerifyMFA(ctx context.Context, req *accountv1.VerifyMFARequest) (*accountv1.VerifyMFAResponse, error) {
+	if req.GetMfaToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "mfa token is empty")
+	}
+	if err := val.CheckMFACode(req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := s.mfa.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode(), sessionInfo(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidChallenge):
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired mfa token")
+		case errors.Is(err, auth.ErrInvalidMFACode), errors.Is(err, auth.ErrMFANotEnabled):
+			return nil, status.Error(codes.Unauthenticated, "invalid code")
+		case errors.Is(err, auth.ErrUserBlocked):
+			return nil, status.Error(codes.PermissionDenied, "user is blocked")
+		case errors.Is(err, auth.ErrEmailNotVerified):
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.VerifyMFAResponse{Tokens: &uservicev1.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}}, nil
+}
+
+func (s *ServerAPI) mfaError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		return status.Error(codes.InvalidArgument, "invalid code")
+	case errors.Is(err, auth.ErrMFANotEnabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	case errors.Is(err, auth.ErrMFADisabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is disabled on this server")
+	}
+	return s.internalError(err)
+}
+
+// mfaRequiredError отдаёт клиенту mfa token для VerifyMFA в ErrorInfo,
+// LoginResponse из внешнего proto не содержит полей для второго шага
+func mfaRequiredError(mfaErr *auth.MFARequiredError) error {
+	st := status.New(codes.FailedPrecondition, "second factor required")
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "MFA_REQUIRED",
+		Domain: "user_profile",
+		Metadata: map[string]string{
+			"mfa_token":  mfaErr.Token,
+			"expires_at": mfaErr.ExpiresAt.UTC().Format(time.RFC3339),
+			"methods":    "totp,recovery_code",
+		},
+	})
+	if err != nil {
+		return status.Error(codes.Internal, "failed to build mfa challenge")
+	}
+	return detailed.Err()
+}
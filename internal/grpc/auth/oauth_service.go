@@ -0,0 +1,58 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OAuthClients регистрирует приложения для OAuth 2.0
+type OAuthClients interface {
+	CreateOAuthClient(ctx context.Context, name string, redirectURIs []string, scopes []string, grantTypes []string) (models.OAuthClient, string, error)
+	DeleteOAuthClient(ctx context.Context, clientID string) error
+}
+
+func (s *ServerAPI) CreateOAuthClient(ctx context.Context, req *accountv1.CreateOAuthClientRequest) (*accountv1.CreateOAuthClientResponse, error) {
+	client, secret, err := s.oauthClients.CreateOAuthClient(ctx, req.GetName(), req.GetRedirectUris(), req.GetScopes(), req.GetGrantTypes())
+	if err != nil {
+		var clientErr *auth.OAuthClientError
+		if errors.As(err, &clientErr) {
+			return nil, status.Error(codes.InvalidArgument, clientErr.Error())
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.CreateOAuthClientResponse{
+		Client: &accountv1.OAuthClient{
+			ClientId:     client.ID,
+			Name:         client.Name,
+			RedirectUris: client.RedirectURIs,
+			Scopes:       client.Scopes,
+			CreatedAt:    timestamppb.New(client.CreatedAt),
+			GrantTypes:   client.GrantTypes,
+			Confidential: client.SecretHash != "",
+		},
+		ClientSecret: secret,
+	}, nil
+}
+
+func (s *ServerAPI) DeleteOAuthClient(ctx context.Context, req *accountv1.DeleteOAuthClientRequest) (*emptypb.Empty, error) {
+	if req.GetClientId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "client ID is empty")
+	}
+
+	if err := s.oauthClients.DeleteOAuthClient(ctx, req.GetClientId()); err != nil {
+		if errors.Is(err, auth.ErrOAuthClientNotFound) {
+			return nil, status.Error(codes.NotFound, "oauth client not found")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,128 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	uservicev1 "github.com/AronditFire/UService-ProtobufNew/gen/user-service"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	val "github.com/AronditFire/User-Service/internal/validator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type Passkeys interface {
+	BeginPasskeyRegistration(ctx context.Context, userID int64) (models.PasskeyCreationOptions, error)
+	FinishPasskeyRegistration(ctx context.Context, userID int64, name string, clientDataJSON []byte, attestationObject []byte) ([]byte, error)
+	BeginPasskeyLogin(ctx context.Context, login string) (models.PasskeyRequestOptions, error)
+	FinishPasskeyLogin(ctx context.Context, assertion models.PasskeyAssertion, info models.SessionInfo) (string, string, error) // access, refresh, error
+}
+
+func (s *ServerAPI) BeginPasskeyRegistration(ctx context.Context, _ *emptypb.Empty) (*accountv1.BeginPasskeyRegistrationResponse, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+
+	options, err := s.passkeys.BeginPasskeyRegistration(ctx, userID)
+	if err != nil {
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.BeginPasskeyRegistrationResponse{
+		Challenge:          options.Challenge,
+		RpId:               options.RPID,
+		RpName:             options.RPName,
+		UserHandle:         options.UserHandle,
+		UserName:           options.UserName,
+		UserDisplayName:    options.UserDisplayName,
+		Algorithms:         options.Algorithms,
+		ExcludeCredentials: options.ExcludeCredentials,
+		TimeoutMs:          options.Timeout.Milliseconds(),
+		UserVerification:   options.UserVerification,
+	}, nil
+}
+
+func (s *ServerAPI) FinishPasskeyRegistration(ctx context.Context, req *accountv1.FinishPasskeyRegistrationRequest) (*accountv1.FinishPasskeyRegistrationResponse, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	if len(req.GetClientDataJson()) == 0 || len(req.GetAttestationObject()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "client data and attestation object are required")
+	}
+	if len(req.GetName()) > 100 {
+		return nil, status.Error(codes.InvalidArgument, "invalid passkey name length")
+	}
+
+	credentialID, err := s.passkeys.FinishPasskeyRegistration(ctx, userID, req.GetName(), req.GetClientDataJson(), req.GetAttestationObject())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidPasskey):
+			return nil, status.Error(codes.InvalidArgument, "passkey registration failed")
+		case errors.Is(err, auth.ErrPasskeyExists):
+			return nil, status.Error(codes.AlreadyExists, "passkey already registered")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.FinishPasskeyRegistrationResponse{CredentialId: credentialID}, nil
+}
+
+func (s *ServerAPI) BeginPasskeyLogin(ctx context.Context, req *accountv1.BeginPasskeyLoginRequest) (*accountv1.BeginPasskeyLoginResponse, error) {
+	if req.GetLogin() != "" {
+		if _, _, err := val.ClassifyLogin(req.GetLogin()); err != nil {
+			return nil, err
+		}
+	}
+
+	options, err := s.passkeys.BeginPasskeyLogin(ctx, req.GetLogin())
+	if err != nil {
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.BeginPasskeyLoginResponse{
+		Challenge:        options.Challenge,
+		RpId:             options.RPID,
+		AllowCredentials: options.AllowCredentials,
+		TimeoutMs:        options.Timeout.Milliseconds(),
+		UserVerification: options.UserVerification,
+	}, nil
+}
+
+// FinishPasskeyLogin is an alternative to Login, issues the same token pair
+func (s *ServerAPI) FinishPasskeyLogin(ctx context.Context, req *accountv1.FinishPasskeyLoginRequest) (*accountv1.FinishPasskeyLoginResponse, error) {
+	if len(req.GetCredentialId()) == 0 || len(req.GetClientDataJson()) == 0 ||
+		len(req.GetAuthenticatorData()) == 0 || len(req.GetSignature()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "credential id, client data, authenticator data and signature are required")
+	}
+
+	accessToken, refreshToken, err := s.passkeys.FinishPasskeyLogin(ctx, models.PasskeyAssertion{
+		CredentialID:      req.GetCredentialId(),
+		ClientDataJSON:    req.GetClientDataJson(),
+		AuthenticatorData: req.GetAuthenticatorData(),
+		Signature:         req.GetSignature(),
+		UserHandle:        req.GetUserHandle(),
+	}, sessionInfo(ctx))
+	if err != nil {
+		var mfaErr *auth.MFARequiredError
+		switch {
+		case errors.As(err, &mfaErr):
+			return nil, mfaRequiredError(mfaErr)
+		case errors.Is(err, auth.ErrInvalidPasskey):
+			return nil, status.Error(codes.Unauthenticated, "passkey verification failed")
+		case errors.Is(err, auth.ErrUserBlocked):
+			return nil, status.Error(codes.PermissionDenied, "user is blocked")
+		case errors.Is(err, auth.ErrEmailNotVerified):
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.FinishPasskeyLoginResponse{Tokens: &uservicev1.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}}, nil
+}
@@ -0,0 +1,108 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	val "github.com/AronditFire/User-Service/internal/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type Passwords interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	ChangePassword(ctx context.Context, userID int64, sessionID string, currentPassword string, newPassword string, keepCurrent bool) error
+}
+
+func (s *ServerAPI) RequestPasswordReset(ctx context.Context, req *accountv1.RequestPasswordResetRequest) (*emptypb.Empty, error) {
+	if err := val.CheckEmail(req.GetEmail()); err != nil {
+		return nil, err
+	}
+
+	if err := s.passwords.RequestPasswordReset(ctx, req.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to request password reset")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) ResetPassword(ctx context.Context, req *accountv1.ResetPasswordRequest) (*emptypb.Empty, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is empty")
+	}
+	if err := val.CheckPassword(req.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	if err := s.passwords.ResetPassword(ctx, req.GetToken(), req.GetNewPassword()); err != nil {
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return nil, passwordPolicyError(policyErr, "newPassword")
+		}
+		if errors.Is(err, auth.ErrInvalidResetToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired password reset token")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) ChangePassword(ctx context.Context, req *accountv1.ChangePasswordRequest) (*emptypb.Empty, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	sessionID, _ := ctx.Value("session_id").(string)
+
+	if req.GetCurrentPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "current password is empty")
+	}
+	if err := val.CheckPassword(req.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	err := s.passwords.ChangePassword(ctx, userID, sessionID, req.GetCurrentPassword(), req.GetNewPassword(), req.GetKeepCurrentSession())
+	if err != nil {
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return nil, passwordPolicyError(policyErr, "newPassword")
+		}
+		var throttled *auth.LoginThrottledError
+		if errors.As(err, &throttled) {
+			return nil, loginThrottledError(ctx, throttled)
+		}
+		switch {
+		case errors.Is(err, auth.ErrWrongPassword):
+			return nil, status.Error(codes.InvalidArgument, "current password is incorrect")
+		case errors.Is(err, auth.ErrSamePassword):
+			return nil, status.Error(codes.InvalidArgument, "new password must differ from the current one")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// passwordPolicyError возвращает InvalidArgument со всеми нарушенными правилами политики паролей
+func passwordPolicyError(err *auth.PasswordPolicyError, field string) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(err.Violations))
+	for i, v := range err.Violations {
+		violations[i] = &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: v.Description,
+			Reason:      v.Reason,
+		}
+	}
+
+	st := status.New(codes.InvalidArgument, "password does not satisfy the password policy")
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -21,6 +21,8 @@ type UserProfile interface {
 	GetProfile(ctx context.Context, userID int64) (*models.UserWithRole, error)
 	GetAllProfiles(ctx context.Context) ([]models.UserWithRole, error)
 	ChangeRole(ctx context.Context, userID int64, role string) error
+	BlockUser(ctx context.Context, userID int64) error
+	UnblockUser(ctx context.Context, userID int64) error
 }
 
 func (s *ServerAPI) GetProfile(ctx context.Context, req *uservicev1.GetProfileRequest) (*uservicev1.UserProfileResponse, error) {
@@ -36,7 +38,7 @@ func (s *ServerAPI) GetProfile(ctx context.Context, req *uservicev1.GetProfileRe
 		if errors.Is(err, uprofile.ErrInvalidCredentials) {
 			return nil, status.Error(codes.NotFound, "user not found")
 		}
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, s.internalError(err)
 	}
 
 	roleStr := strings.ToUpper(user.Role) // "BUYER", "ADMIN"
@@ -60,7 +62,7 @@ func (s *ServerAPI) GetProfile(ctx context.Context, req *uservicev1.GetProfileRe
 func (s *ServerAPI) ListUsers(ctx context.Context, _ *emptypb.Empty) (*uservicev1.UserListResponse, error) {
 	users, err := s.uProf.GetAllProfiles(ctx)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, s.internalError(err)
 	}
 	resp := &uservicev1.UserListResponse{Users: make([]*uservicev1.UserProfileResponse, len(users))}
 	for i, user := range users {
@@ -94,7 +96,7 @@ func (s *ServerAPI) ChangeRole(ctx context.Context, req *uservicev1.AdminRoleReq
 		if errors.Is(err, uprofile.ErrInvalidCredentials) {
 			return nil, status.Error(codes.NotFound, "user not found")
 		}
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, s.internalError(err)
 	}
 
 	return &emptypb.Empty{}, nil
@@ -0,0 +1,77 @@
+package authgrpc
+
+import (
+	"context"
+	"github.com/AronditFire/User-Service/internal/lib/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"log/slog"
+	"net/netip"
+	"strconv"
+)
+
+// RateLimits лимиты по полному имени метода (/user_profile.UserService/Login), остальные методы получают Default
+type RateLimits struct {
+	Default ratelimit.Limit
+	Methods map[string]ratelimit.Limit
+}
+
+func (r RateLimits) limit(method string) ratelimit.Limit {
+	if l, ok := r.Methods[method]; ok {
+		return l
+	}
+	return r.Default
+}
+
+// UnaryRateLimitInterceptor ограничивает каждый метод отдельно по адресу клиента
+// и, если есть токен, еще по пользователю или OAuth клиенту.
+// Ставится после UnaryAuthInterceptor, чтобы user_id уже был в контексте.
+func UnaryRateLimitInterceptor(log *slog.Logger, limiter ratelimit.Limiter, limits RateLimits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limit := limits.limit(info.FullMethod)
+		if limit.Unlimited() {
+			return handler(ctx, req)
+		}
+
+		for _, client := range rateLimitClients(ctx) {
+			key := info.FullMethod + "|" + client
+			allowed, retryAfter, err := limiter.Allow(ctx, key, limit)
+			if err != nil {
+				// лимитер недоступен - пропускаем запрос, а не отказываем всем
+				log.Error("rate limiter failed", slog.String("method", info.FullMethod), slog.String("error", err.Error()))
+				return handler(ctx, req)
+			}
+			if !allowed {
+				log.Warn("rate limit exceeded", slog.String("key", key))
+				return nil, retryAfterError(ctx, codes.ResourceExhausted, "rate limit exceeded, try again later", retryAfter)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitClients ключи, по которым лимитируется запрос: адрес всегда, пользователь или OAuth клиент из токена
+func rateLimitClients(ctx context.Context) []string {
+	clients := []string{"ip:" + rateLimitIP(clientIP(ctx))}
+	if clientID, ok := ctx.Value("client_id").(string); ok {
+		clients = append(clients, "client:"+clientID)
+	} else if userID, ok := ctx.Value("user_id").(int64); ok {
+		clients = append(clients, "user:"+strconv.FormatInt(userID, 10))
+	}
+	return clients
+}
+
+// rateLimitIP адрес соединения (или клиента за доверенным прокси); IPv6 группируется по /64,
+// иначе один клиент получает лимит на каждый адрес своей подсети
+func rateLimitIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.Is4() || addr.Is4In6() {
+		return ip
+	}
+	prefix, err := addr.Prefix(64)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
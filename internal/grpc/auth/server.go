@@ -2,42 +2,156 @@ package authgrpc
 
 import (
 	"context"
+	"errors"
 	uservicev1 "github.com/AronditFire/UService-ProtobufNew/gen/user-service"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
 	"github.com/AronditFire/User-Service/internal/lib/jwt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"log/slog"
+	"slices"
 	"strings"
 )
 
 type ServerAPI struct {
 	uservicev1.UnimplementedUserServiceServer
-	auth  Auth
-	uProf UserProfile
+	accountv1.UnimplementedSessionServiceServer
+	accountv1.UnimplementedAdminServiceServer
+	accountv1.UnimplementedKeyServiceServer
+	accountv1.UnimplementedIntrospectionServiceServer
+	accountv1.UnimplementedMFAServiceServer
+	accountv1.UnimplementedPasskeyServiceServer
+	accountv1.UnimplementedVerificationServiceServer
+	accountv1.UnimplementedPasswordServiceServer
+	accountv1.UnimplementedOAuthClientServiceServer
+	log          *slog.Logger
+	auth         Auth
+	uProf        UserProfile
+	sessions     Sessions
+	mfa          MFA
+	passkeys     Passkeys
+	verification Verification
+	passwords    Passwords
+	lockout      Lockout
+	oauthClients OAuthClients
+	keys         KeyProvider
+	introspector Introspector
 }
 
-func RegisterUserService(s *grpc.Server, auth Auth, uProf UserProfile) {
-	uservicev1.RegisterUserServiceServer(s, &ServerAPI{
-		auth:  auth,
-		uProf: uProf,
-	})
+// Services реализации gRPC сервисов; один сервис приложения может закрывать несколько полей
+type Services struct {
+	Auth         Auth
+	Profile      UserProfile
+	Sessions     Sessions
+	MFA          MFA
+	Passkeys     Passkeys
+	Verification Verification
+	Passwords    Passwords
+	Lockout      Lockout
+	OAuthClients OAuthClients
+	Keys         KeyProvider
+	Introspector Introspector
 }
 
-func UnaryAuthInterceptor(jwtSecret string) grpc.UnaryServerInterceptor {
+func RegisterUserService(s *grpc.Server, log *slog.Logger, services Services) {
+	api := &ServerAPI{
+		log:          log,
+		auth:         services.Auth,
+		uProf:        services.Profile,
+		sessions:     services.Sessions,
+		mfa:          services.MFA,
+		passkeys:     services.Passkeys,
+		verification: services.Verification,
+		passwords:    services.Passwords,
+		lockout:      services.Lockout,
+		oauthClients: services.OAuthClients,
+		keys:         services.Keys,
+		introspector: services.Introspector,
+	}
+
+	uservicev1.RegisterUserServiceServer(s, api)
+	accountv1.RegisterSessionServiceServer(s, api)
+	accountv1.RegisterAdminServiceServer(s, api)
+	accountv1.RegisterKeyServiceServer(s, api)
+	accountv1.RegisterIntrospectionServiceServer(s, api)
+	accountv1.RegisterMFAServiceServer(s, api)
+	accountv1.RegisterPasskeyServiceServer(s, api)
+	accountv1.RegisterVerificationServiceServer(s, api)
+	accountv1.RegisterPasswordServiceServer(s, api)
+	accountv1.RegisterOAuthClientServiceServer(s, api)
+}
+
+// RevocationChecker reports whether an access token was revoked before its expiry
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) bool
+}
+
+func UnaryAuthInterceptor(keys *jwt.KeySet, denylist RevocationChecker) grpc.UnaryServerInterceptor {
 	// Определяем списки методов по уровню доступа
 	publicMethods := map[string]struct{}{
-		"/user_profile.UserService/Register":     {},
-		"/user_profile.UserService/Login":        {},
-		"/user_profile.UserService/RefreshToken": {},
-		"/user_profile.UserService/Logout":       {},
+		"/user_profile.UserService/Register":              {},
+		"/user_profile.UserService/Login":                 {},
+		"/user_profile.UserService/RefreshToken":          {},
+		"/user_profile.UserService/Logout":                {},
+		"/user_profile.KeyService/GetJWKS":                {},
+		"/user_profile.MFAService/VerifyMFA":              {}, // второй шаг логина, токена ещё нет
+		"/user_profile.PasskeyService/BeginPasskeyLogin":  {},
+		"/user_profile.PasskeyService/FinishPasskeyLogin": {},
+		// без входа, если вход запрещён до подтверждения email
+		"/user_profile.VerificationService/SendVerificationEmail": {},
+		"/user_profile.VerificationService/VerifyEmail":           {},
+		"/user_profile.PasswordService/RequestPasswordReset":      {},
+		"/user_profile.PasswordService/ResetPassword":             {},
 	}
 	buyerMethods := map[string]struct{}{
-		"/user_profile.UserService/GetProfile": {},
+		"/user_profile.UserService/GetProfile":                   {},
+		"/user_profile.SessionService/ListSessions":              {},
+		"/user_profile.SessionService/RevokeSession":             {},
+		"/user_profile.SessionService/RevokeAllSessions":         {},
+		"/user_profile.MFAService/EnrollTOTP":                    {},
+		"/user_profile.MFAService/ConfirmTOTP":                   {},
+		"/user_profile.MFAService/DisableTOTP":                   {},
+		"/user_profile.MFAService/RegenerateRecoveryCodes":       {},
+		"/user_profile.PasskeyService/BeginPasskeyRegistration":  {},
+		"/user_profile.PasskeyService/FinishPasskeyRegistration": {},
+		"/user_profile.VerificationService/RequestPhoneOTP":      {},
+		"/user_profile.VerificationService/VerifyPhone":          {},
+		"/user_profile.PasswordService/ChangePassword":           {},
 	}
 	adminMethods := map[string]struct{}{
-		"/user_profile.UserService/ListUsers":  {},
-		"/user_profile.UserService/ChangeRole": {},
+		"/user_profile.UserService/ListUsers":                 {},
+		"/user_profile.UserService/ChangeRole":                {},
+		"/user_profile.SessionService/AdminListSessions":      {},
+		"/user_profile.SessionService/AdminRevokeSession":     {},
+		"/user_profile.SessionService/AdminRevokeAllSessions": {},
+		"/user_profile.AdminService/BlockUser":                {},
+		"/user_profile.AdminService/UnblockUser":              {},
+		"/user_profile.AdminService/UnlockAccount":            {},
+		"/user_profile.OAuthClientService/CreateOAuthClient":  {},
+		"/user_profile.OAuthClientService/DeleteOAuthClient":  {},
+	}
+	// только для сервисов с токеном client_credentials и нужным scope
+	clientMethods := map[string]struct{}{
+		"/user_profile.IntrospectionService/IntrospectToken": {},
+	}
+	// токены OAuth клиентов открывают только методы выданных scope'ов (auth.OAuthScopes)
+	scopeMethods := map[string][]string{
+		"profile": {
+			"/user_profile.UserService/GetProfile",
+		},
+		"sessions": {
+			"/user_profile.SessionService/ListSessions",
+			"/user_profile.SessionService/RevokeSession",
+			"/user_profile.SessionService/RevokeAllSessions",
+		},
+		"users:read": {
+			"/user_profile.UserService/ListUsers",
+		},
+		"introspect": {
+			"/user_profile.IntrospectionService/IntrospectToken",
+		},
 	}
 
 	return func(
@@ -67,29 +181,82 @@ func UnaryAuthInterceptor(jwtSecret string) grpc.UnaryServerInterceptor {
 		token := parts[1]
 
 		// 3) Верифицируем JWT
-		claims, err := jwt.VerifyToken(token, jwtSecret)
+		claims, err := keys.VerifyToken(token)
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+			return nil, status.Error(codes.Unauthenticated, tokenErrorMessage(err))
+		}
+		if claims.ID == "" || denylist.IsRevoked(ctx, claims.ID) {
+			return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+		}
+
+		// 4) Токен OAuth клиента: метод должен входить в один из scope'ов
+		if claims.ClientID != "" && !scopeAllows(scopeMethods, claims.Scopes(), info.FullMethod) {
+			return nil, status.Error(codes.PermissionDenied, "insufficient scope")
+		}
+
+		// методы сервисов: только токен client_credentials, пользовательский токен их не открывает
+		if _, ok := clientMethods[info.FullMethod]; ok && !claims.IsClient() {
+			return nil, status.Error(codes.PermissionDenied, "client credentials token required")
 		}
 
-		// 4) Проверяем роль для buyerMethods
+		// Токен сервиса (client_credentials): пользователя и роли нет, доступ определяют только scope'ы
+		if claims.IsClient() {
+			ctx = context.WithValue(ctx, "client_id", claims.ClientID)
+			return handler(ctx, req)
+		}
+
+		// 5) Проверяем роль для buyerMethods
 		if _, ok := buyerMethods[info.FullMethod]; ok {
 			if claims.Role != "buyer" && claims.Role != "admin" {
 				return nil, status.Error(codes.PermissionDenied, "buyer role required")
 			}
 		}
-		// 5) Проверяем роль для adminMethods
+		// 6) Проверяем роль для adminMethods
 		if _, ok := adminMethods[info.FullMethod]; ok {
 			if claims.Role != "admin" {
 				return nil, status.Error(codes.PermissionDenied, "admin role required")
 			}
 		}
 
-		// 6) Кладём в контекст
+		// 7) Кладём в контекст
 		ctx = context.WithValue(ctx, "user_id", claims.UserID)
 		ctx = context.WithValue(ctx, "role", claims.Role)
+		ctx = context.WithValue(ctx, "session_id", claims.SessionID)
 
-		// 7) Вызов обработчика
+		// 8) Вызов обработчика
 		return handler(ctx, req)
 	}
 }
+
+// internalError логирует причину, клиент получает только "internal error":
+// в обёрнутых ошибках сервисов и storage есть op и тексты запросов
+func (s *ServerAPI) internalError(err error) error {
+	s.log.Error("request failed", slog.String("error", err.Error()))
+	return status.Error(codes.Internal, "internal error")
+}
+
+func scopeAllows(scopeMethods map[string][]string, scopes []string, method string) bool {
+	for _, scope := range scopes {
+		if slices.Contains(scopeMethods[scope], method) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenErrorMessage не раскрывает деталей проверки, только причину отказа
+func tokenErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token is expired"
+	case errors.Is(err, jwt.ErrTokenNotYetValid):
+		return "token is not valid yet"
+	case errors.Is(err, jwt.ErrWrongIssuer), errors.Is(err, jwt.ErrWrongAudience):
+		return "token is not issued for this service"
+	case errors.Is(err, jwt.ErrBadSignature):
+		return "invalid token signature"
+	case errors.Is(err, jwt.ErrMalformedToken):
+		return "malformed token"
+	}
+	return "invalid token"
+}
@@ -0,0 +1,141 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Sessions interface {
+	ListSessions(ctx context.Context, userID int64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID int64, keepSessionID string) error
+}
+
+func (s *ServerAPI) ListSessions(ctx context.Context, _ *emptypb.Empty) (*accountv1.ListSessionsResponse, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	currentSession, _ := ctx.Value("session_id").(string)
+
+	return s.listSessions(ctx, userID, currentSession)
+}
+
+func (s *ServerAPI) RevokeSession(ctx context.Context, req *accountv1.RevokeSessionRequest) (*emptypb.Empty, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+
+	return s.revokeSession(ctx, userID, req.GetSessionId())
+}
+
+func (s *ServerAPI) RevokeAllSessions(ctx context.Context, req *accountv1.RevokeAllSessionsRequest) (*emptypb.Empty, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+
+	var keepSession string
+	if req.GetKeepCurrent() {
+		keepSession, _ = ctx.Value("session_id").(string)
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, userID, keepSession); err != nil {
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) AdminListSessions(ctx context.Context, req *accountv1.AdminSessionsRequest) (*accountv1.ListSessionsResponse, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be greater than 0")
+	}
+
+	return s.listSessions(ctx, req.GetUserId(), "")
+}
+
+func (s *ServerAPI) AdminRevokeSession(ctx context.Context, req *accountv1.AdminRevokeSessionRequest) (*emptypb.Empty, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be greater than 0")
+	}
+
+	return s.revokeSession(ctx, req.GetUserId(), req.GetSessionId())
+}
+
+func (s *ServerAPI) AdminRevokeAllSessions(ctx context.Context, req *accountv1.AdminSessionsRequest) (*emptypb.Empty, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be greater than 0")
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, req.GetUserId(), ""); err != nil {
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) listSessions(ctx context.Context, userID int64, currentSession string) (*accountv1.ListSessionsResponse, error) {
+	sessions, err := s.sessions.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, s.internalError(err)
+	}
+
+	resp := &accountv1.ListSessionsResponse{Sessions: make([]*accountv1.Session, len(sessions))}
+	for i, session := range sessions {
+		resp.Sessions[i] = &accountv1.Session{
+			SessionId:  session.ID,
+			DeviceName: session.Info.DeviceName,
+			UserAgent:  session.Info.UserAgent,
+			Ip:         session.Info.IP,
+			CreatedAt:  timestamppb.New(session.CreatedAt),
+			LastUsedAt: timestamppb.New(session.LastUsedAt),
+			ExpiresAt:  timestamppb.New(session.ExpiresAt),
+			Current:    currentSession != "" && session.ID == currentSession,
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *ServerAPI) revokeSession(ctx context.Context, userID int64, sessionID string) (*emptypb.Empty, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid session ID")
+	}
+
+	if err := s.sessions.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// sessionInfo собирает данные о клиенте из метаданных запроса, адрес - из clientIP
+func sessionInfo(ctx context.Context) models.SessionInfo {
+	var info models.SessionInfo
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("x-device-name"); len(v) > 0 {
+		info.DeviceName = v[0]
+	}
+	if v := md.Get("user-agent"); len(v) > 0 {
+		info.UserAgent = v[0]
+	}
+
+	info.IP = clientIP(ctx)
+
+	return info
+}
@@ -0,0 +1,87 @@
+package authgrpc
+
+import (
+	"context"
+	"errors"
+	accountv1 "github.com/AronditFire/User-Service/gen/account"
+	"github.com/AronditFire/User-Service/internal/services/verification"
+	val "github.com/AronditFire/User-Service/internal/validator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+type Verification interface {
+	SendVerificationEmail(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	RequestPhoneOTP(ctx context.Context, userID int64) (time.Time, error) // expires at
+	VerifyPhone(ctx context.Context, userID int64, code string) error
+}
+
+func (s *ServerAPI) SendVerificationEmail(ctx context.Context, req *accountv1.SendVerificationEmailRequest) (*emptypb.Empty, error) {
+	if err := val.CheckEmail(req.GetEmail()); err != nil {
+		return nil, err
+	}
+
+	if err := s.verification.SendVerificationEmail(ctx, req.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to send verification email")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) VerifyEmail(ctx context.Context, req *accountv1.VerifyEmailRequest) (*emptypb.Empty, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is empty")
+	}
+
+	if err := s.verification.VerifyEmail(ctx, req.GetToken()); err != nil {
+		if errors.Is(err, verification.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired verification token")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ServerAPI) RequestPhoneOTP(ctx context.Context, _ *emptypb.Empty) (*accountv1.RequestPhoneOTPResponse, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+
+	expiresAt, err := s.verification.RequestPhoneOTP(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, verification.ErrOTPTooFrequent):
+			return nil, status.Error(codes.ResourceExhausted, "code was requested too recently")
+		case errors.Is(err, verification.ErrPhoneAlreadyVerified):
+			return nil, status.Error(codes.FailedPrecondition, "phone number is already verified")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &accountv1.RequestPhoneOTPResponse{ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *ServerAPI) VerifyPhone(ctx context.Context, req *accountv1.VerifyPhoneRequest) (*emptypb.Empty, error) {
+	userID, ok := ctx.Value("user_id").(int64)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "user ID is missing")
+	}
+	if err := val.CheckMFACode(req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	if err := s.verification.VerifyPhone(ctx, userID, req.GetCode()); err != nil {
+		if errors.Is(err, verification.ErrInvalidCode) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired code")
+		}
+		return nil, s.internalError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
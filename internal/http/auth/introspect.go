@@ -0,0 +1,95 @@
+package authhttp
+
+import (
+	"errors"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	"log/slog"
+	"net/http"
+)
+
+// IntrospectionResponse ответ по RFC 7662, для неактивного токена только active=false
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Subject   string   `json:"sub,omitempty"`
+	UserID    int64    `json:"user_id,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	TokenID   string   `json:"jti,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+}
+
+// Introspect принимает application/x-www-form-urlencoded с полем token (RFC 7662).
+// Вызывающий сервис аутентифицируется как OAuth клиент со scope introspect: Basic или client_id/client_secret в форме.
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, clientSecret, basic, ok := clientCredentials(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		h.writeOAuthError(w, http.StatusUnauthorized, auth.OAuthInvalidClient, "client authentication required")
+		return
+	}
+	if err := h.introspector.AuthenticateIntrospectionClient(r.Context(), clientID, clientSecret); err != nil {
+		var oauthErr *auth.OAuthError
+		switch {
+		case errors.As(err, &oauthErr) && oauthErr.Code == auth.OAuthInvalidClient:
+			if basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+			}
+			h.writeOAuthError(w, http.StatusUnauthorized, oauthErr.Code, oauthErr.Description)
+		case errors.As(err, &oauthErr):
+			h.writeOAuthError(w, http.StatusForbidden, oauthErr.Code, oauthErr.Description)
+		default:
+			h.log.Error("failed to authenticate introspection client", slog.String("error", err.Error()))
+			h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		}
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	// token_type_hint не учитывается, как и в gRPC (RFC 7662, 2.1)
+
+	result, err := h.introspector.Introspect(r.Context(), token)
+	if err != nil {
+		h.log.Error("failed to introspect token", slog.String("error", err.Error()))
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	if !result.Active {
+		h.writeJSON(w, http.StatusOK, IntrospectionResponse{Active: false})
+		return
+	}
+
+	resp := IntrospectionResponse{
+		Active:    true,
+		Subject:   result.Subject,
+		UserID:    result.UserID,
+		SessionID: result.SessionID,
+		TokenID:   result.TokenID,
+		Issuer:    result.Issuer,
+		Audience:  result.Audience,
+		ExpiresAt: result.ExpiresAt.Unix(),
+		TokenType: "access_token",
+		Scope:     result.Scope,
+		ClientID:  result.ClientID,
+	}
+	if result.Role != "" { // у токенов client_credentials нет пользователя и роли
+		resp.Roles = []string{result.Role}
+	}
+	if !result.IssuedAt.IsZero() {
+		resp.IssuedAt = result.IssuedAt.Unix()
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,255 @@
+package authhttp
+
+import (
+	"context"
+	"errors"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OAuth interface {
+	Authorize(ctx context.Context, req auth.AuthorizeRequest) (string, error)
+	AuthorizationRequest(ctx context.Context, requestID string) (models.AuthorizationRequest, models.OAuthClient, error)
+	Consent(ctx context.Context, accessToken, requestID string, approve bool) (string, error)
+	ExchangeAuthorizationCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string, info models.SessionInfo) (auth.OAuthTokens, error)
+	RefreshOAuthToken(ctx context.Context, clientID, clientSecret, refreshToken, scope string, info models.SessionInfo) (auth.OAuthTokens, error)
+	ClientCredentials(ctx context.Context, clientID, clientSecret, scope string) (auth.OAuthTokens, error)
+}
+
+// OAuthErrorResponse ошибка по RFC 6749, 5.2
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// TokenResponse ответ token endpoint (RFC 6749, 5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type ScopeInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AuthorizationRequestResponse то, что страница согласия показывает пользователю
+type AuthorizationRequestResponse struct {
+	ClientID    string      `json:"client_id"`
+	ClientName  string      `json:"client_name"`
+	RedirectURI string      `json:"redirect_uri"`
+	Scopes      []ScopeInfo `json:"scopes"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+}
+
+type ConsentResponse struct {
+	RedirectURI string `json:"redirect_uri"` // куда фронтенд отправляет браузер
+}
+
+// Authorize проверяет запрос клиента и отправляет браузер на страницу входа и согласия
+func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	consentURL, err := h.oauth.Authorize(r.Context(), auth.AuthorizeRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	})
+	if err != nil {
+		var oauthErr *auth.OAuthError
+		if errors.As(err, &oauthErr) {
+			if oauthErr.Redirect != "" {
+				http.Redirect(w, r, oauthErr.Redirect, http.StatusFound)
+				return
+			}
+			h.writeOAuthError(w, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		h.log.Error("failed to authorize", slog.String("error", err.Error()))
+		h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	http.Redirect(w, r, consentURL, http.StatusFound)
+}
+
+// AuthorizationRequest отдаёт странице согласия клиента и запрошенные scope'ы
+func (h *Handler) AuthorizationRequest(w http.ResponseWriter, r *http.Request) {
+	req, client, err := h.oauth.AuthorizationRequest(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, auth.ErrAuthorizationRequestNotFound) {
+			h.writeOAuthError(w, http.StatusNotFound, auth.OAuthInvalidRequest, "authorization request not found or expired")
+			return
+		}
+		h.log.Error("failed to get authorization request", slog.String("error", err.Error()))
+		h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	scopes := make([]ScopeInfo, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = ScopeInfo{Name: scope, Description: auth.OAuthScopes[scope]}
+	}
+	h.writeJSON(w, http.StatusOK, AuthorizationRequestResponse{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		RedirectURI: req.RedirectURI,
+		Scopes:      scopes,
+		ExpiresAt:   req.ExpiresAt,
+	})
+}
+
+// Consent принимает решение пользователя: form поля request_id и approve, access токен в Authorization
+func (h *Handler) Consent(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		h.writeOAuthError(w, http.StatusUnauthorized, "invalid_token", "authorization header required")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "")
+		return
+	}
+	requestID := r.PostForm.Get("request_id")
+	approve, err := strconv.ParseBool(r.PostForm.Get("approve"))
+	if requestID == "" || err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "request_id and approve are required")
+		return
+	}
+
+	redirect, err := h.oauth.Consent(r.Context(), token, requestID, approve)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			h.writeOAuthError(w, http.StatusUnauthorized, "invalid_token", "")
+		case errors.Is(err, auth.ErrAuthorizationRequestNotFound):
+			h.writeOAuthError(w, http.StatusNotFound, auth.OAuthInvalidRequest, "authorization request not found or expired")
+		default:
+			h.log.Error("failed to save consent", slog.String("error", err.Error()))
+			h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, ConsentResponse{RedirectURI: redirect})
+}
+
+// Token выдаёт токены по grant_type authorization_code, refresh_token и client_credentials (RFC 6749, 4.1.3, 6 и 4.4).
+// Секрет клиента принимается в Authorization: Basic или в полях формы.
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "")
+		return
+	}
+	form := r.PostForm
+	clientID, clientSecret, basic, ok := clientCredentials(r)
+	if !ok {
+		h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "client_id is required")
+		return
+	}
+
+	var (
+		tokens auth.OAuthTokens
+		err    error
+	)
+	switch form.Get("grant_type") {
+	case "authorization_code":
+		if form.Get("code") == "" || form.Get("redirect_uri") == "" || form.Get("code_verifier") == "" {
+			h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "code, redirect_uri and code_verifier are required")
+			return
+		}
+		tokens, err = h.oauth.ExchangeAuthorizationCode(r.Context(), clientID, clientSecret, form.Get("code"), form.Get("redirect_uri"),
+			form.Get("code_verifier"), h.sessionInfo(r))
+	case "refresh_token":
+		if form.Get("refresh_token") == "" {
+			h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthInvalidRequest, "refresh_token is required")
+			return
+		}
+		tokens, err = h.oauth.RefreshOAuthToken(r.Context(), clientID, clientSecret, form.Get("refresh_token"), form.Get("scope"), h.sessionInfo(r))
+	case "client_credentials":
+		tokens, err = h.oauth.ClientCredentials(r.Context(), clientID, clientSecret, form.Get("scope"))
+	default:
+		h.writeOAuthError(w, http.StatusBadRequest, auth.OAuthUnsupportedGrantType, "")
+		return
+	}
+	if err != nil {
+		var oauthErr *auth.OAuthError
+		if errors.As(err, &oauthErr) {
+			code := http.StatusBadRequest
+			if oauthErr.Code == auth.OAuthInvalidClient {
+				code = http.StatusUnauthorized
+				if basic {
+					w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+				}
+			}
+			h.writeOAuthError(w, code, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		h.log.Error("failed to issue oauth tokens", slog.String("error", err.Error()))
+		h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        strings.Join(tokens.Scopes, " "),
+	})
+}
+
+func (h *Handler) writeOAuthError(w http.ResponseWriter, code int, oauthCode, description string) {
+	h.writeJSON(w, code, OAuthErrorResponse{Error: oauthCode, ErrorDescription: description})
+}
+
+// clientCredentials берёт client_id и секрет из Authorization: Basic (RFC 6749, 2.3.1) или из формы;
+// basic сообщает, что использовался заголовок
+func clientCredentials(r *http.Request) (clientID, secret string, basic, ok bool) {
+	if id, pass, hasBasic := r.BasicAuth(); hasBasic {
+		// в Basic значения закодированы как application/x-www-form-urlencoded
+		id, idErr := url.QueryUnescape(id)
+		pass, passErr := url.QueryUnescape(pass)
+		if idErr != nil || passErr != nil || id == "" {
+			return "", "", true, false
+		}
+		return id, pass, true, true
+	}
+
+	clientID = r.PostForm.Get("client_id")
+	return clientID, r.PostForm.Get("client_secret"), false, clientID != ""
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// sessionInfo как в gRPC: X-Device-Name, User-Agent и адрес клиента; X-Forwarded-For учитывается только от доверенных прокси
+func (h *Handler) sessionInfo(r *http.Request) models.SessionInfo {
+	return models.SessionInfo{
+		DeviceName: r.Header.Get("X-Device-Name"),
+		UserAgent:  r.UserAgent(),
+		IP:         h.clientIP.Resolve(r.RemoteAddr, r.Header.Values("X-Forwarded-For")),
+	}
+}
@@ -0,0 +1,61 @@
+package authhttp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/clientip"
+	"github.com/AronditFire/User-Service/internal/lib/jwt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type KeyProvider interface {
+	JWKS() jwt.JWKS
+}
+
+type Introspector interface {
+	Introspect(ctx context.Context, accessToken string) (models.TokenIntrospection, error)
+	AuthenticateIntrospectionClient(ctx context.Context, clientID, clientSecret string) error
+}
+
+type Handler struct {
+	log          *slog.Logger
+	keys         KeyProvider
+	introspector Introspector
+	oauth        OAuth
+	clientIP     *clientip.Resolver
+	issuer       string
+}
+
+// NewRouter собирает HTTP маршруты сервиса
+func NewRouter(log *slog.Logger, keys KeyProvider, introspector Introspector, oauth OAuth, clientIP *clientip.Resolver, issuer string) http.Handler {
+	h := &Handler{
+		log:          log,
+		keys:         keys,
+		introspector: introspector,
+		oauth:        oauth,
+		clientIP:     clientIP,
+		issuer:       strings.TrimSuffix(issuer, "/"),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", h.JWKS)
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", h.AuthorizationServerMetadata)
+	mux.HandleFunc("POST /oauth2/introspect", h.Introspect)
+	mux.HandleFunc("GET /oauth2/authorize", h.Authorize)
+	mux.HandleFunc("GET /oauth2/authorize/requests/{id}", h.AuthorizationRequest)
+	mux.HandleFunc("POST /oauth2/authorize/consent", h.Consent)
+	mux.HandleFunc("POST /oauth2/token", h.Token)
+
+	return mux
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.log.Error("failed to write response", slog.String("error", err.Error()))
+	}
+}
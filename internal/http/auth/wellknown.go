@@ -0,0 +1,47 @@
+package authhttp
+
+import (
+	"github.com/AronditFire/User-Service/internal/services/auth"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// AuthorizationServerMetadata метаданные сервера авторизации (RFC 8414).
+// ID токены не выпускаются, поэтому /.well-known/openid-configuration не отдаётся
+type AuthorizationServerMetadata struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IntrospectionEndpoint            string   `json:"introspection_endpoint"`
+	IntrospectionEndpointAuthMethods []string `json:"introspection_endpoint_auth_methods_supported"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	GrantTypesSupported              []string `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported    []string `json:"code_challenge_methods_supported"`
+	TokenEndpointAuthMethods         []string `json:"token_endpoint_auth_methods_supported"`
+	ScopesSupported                  []string `json:"scopes_supported"`
+}
+
+// JWKS отдаёт публичные ключи, которыми можно проверить access токены
+func (h *Handler) JWKS(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.writeJSON(w, http.StatusOK, h.keys.JWKS())
+}
+
+func (h *Handler) AuthorizationServerMetadata(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.writeJSON(w, http.StatusOK, AuthorizationServerMetadata{
+		Issuer:                           h.issuer,
+		JWKSURI:                          h.issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:            h.issuer + "/oauth2/introspect",
+		IntrospectionEndpointAuthMethods: []string{"client_secret_basic", "client_secret_post"},
+		AuthorizationEndpoint:            h.issuer + "/oauth2/authorize",
+		TokenEndpoint:                    h.issuer + "/oauth2/token",
+		ResponseTypesSupported:           []string{"code"},
+		GrantTypesSupported:              []string{"authorization_code", "refresh_token", "client_credentials"},
+		CodeChallengeMethodsSupported:    []string{"S256"},
+		TokenEndpointAuthMethods:         []string{"none", "client_secret_basic", "client_secret_post"},
+		ScopesSupported:                  slices.Sorted(maps.Keys(auth.OAuthScopes)),
+	})
+}
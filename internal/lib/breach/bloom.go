@@ -0,0 +1,118 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// заголовок файла: magic, k (uint32), m (uint64), затем m/64 слов little-endian
+const bloomMagic = "PWBF"
+
+var ErrInvalidBloom = errors.New("invalid bloom filter file")
+
+// Bloom компактный фильтр по SHA-1 паролей. Ложные срабатывания возможны с заданной вероятностью,
+// пропусков нет.
+type Bloom struct {
+	k    uint32
+	m    uint64
+	bits []uint64
+}
+
+// NewBloom рассчитывает размер фильтра под n хешей и вероятность ложного срабатывания fpRate
+func NewBloom(n uint64, fpRate float64) *Bloom {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := uint32(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &Bloom{k: k, m: m, bits: make([]uint64, m/64)}
+}
+
+func (b *Bloom) Add(hash [sha1.Size]byte) {
+	h1, h2 := bloomHashes(hash)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		pos := (h1 + i*h2) % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+func (b *Bloom) ContainsHash(hash [sha1.Size]byte) bool {
+	h1, h2 := bloomHashes(hash)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bloom) Contains(password string) (bool, error) {
+	return b.ContainsHash(Hash(password)), nil
+}
+
+func (b *Bloom) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, len(bloomMagic)+4+8)
+	copy(header, bloomMagic)
+	binary.LittleEndian.PutUint32(header[4:], b.k)
+	binary.LittleEndian.PutUint64(header[8:], b.m)
+	written, err := bw.Write(header)
+	total := int64(written)
+	if err != nil {
+		return total, err
+	}
+
+	word := make([]byte, 8)
+	for _, v := range b.bits {
+		binary.LittleEndian.PutUint64(word, v)
+		written, err = bw.Write(word)
+		total += int64(written)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, bw.Flush()
+}
+
+func ReadBloom(r io.Reader) (*Bloom, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(bloomMagic)+4+8)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, ErrInvalidBloom
+	}
+	if string(header[:4]) != bloomMagic {
+		return nil, ErrInvalidBloom
+	}
+	k := binary.LittleEndian.Uint32(header[4:])
+	m := binary.LittleEndian.Uint64(header[8:])
+	if k == 0 || m == 0 || m%64 != 0 {
+		return nil, ErrInvalidBloom
+	}
+
+	bits := make([]uint64, m/64)
+	word := make([]byte, 8)
+	for i := range bits {
+		if _, err := io.ReadFull(br, word); err != nil {
+			return nil, ErrInvalidBloom
+		}
+		bits[i] = binary.LittleEndian.Uint64(word)
+	}
+
+	return &Bloom{k: k, m: m, bits: bits}, nil
+}
+
+// SHA-1 уже равномерно распределен, поэтому вместо k хеш-функций берем две части дайджеста
+// (double hashing)
+func bloomHashes(hash [sha1.Size]byte) (uint64, uint64) {
+	return binary.BigEndian.Uint64(hash[0:8]), binary.BigEndian.Uint64(hash[8:16]) | 1
+}
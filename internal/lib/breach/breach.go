@@ -0,0 +1,82 @@
+// Package breach проверяет пароли по локальной базе утечек в формате HIBP (SHA-1),
+// без обращения к внешним сервисам
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+var ErrInvalidLine = errors.New("invalid corpus line")
+
+// Corpus отвечает, встречался ли пароль в утечках
+type Corpus interface {
+	Contains(password string) (bool, error)
+}
+
+// Open открывает фильтр Блума, собранный cmd/breachfilter, или отсортированный файл HIBP.
+// Формат определяется по заголовку файла.
+func Open(path string) (Corpus, error) {
+	const op = "breach.Open"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	magic := make([]byte, len(bloomMagic))
+	if _, err := io.ReadFull(f, magic); err == nil && string(magic) == bloomMagic {
+		defer f.Close()
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		bloom, err := ReadBloom(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return bloom, nil
+	}
+
+	sorted, err := newSortedFile(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sorted, nil
+}
+
+// Hash считает SHA-1 пароля, как в базе HIBP
+func Hash(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// ParseLine разбирает строку "SHA1:COUNT" из файла HIBP, без ":COUNT" count равен 1
+func ParseLine(line []byte) ([sha1.Size]byte, int64, error) {
+	var hash [sha1.Size]byte
+
+	line = bytes.TrimRight(line, "\r\n")
+	hexHash, countStr, hasCount := bytes.Cut(line, []byte(":"))
+	if len(hexHash) != hex.EncodedLen(sha1.Size) {
+		return hash, 0, ErrInvalidLine
+	}
+	if _, err := hex.Decode(hash[:], hexHash); err != nil {
+		return hash, 0, ErrInvalidLine
+	}
+
+	count := int64(1)
+	if hasCount {
+		n, err := strconv.ParseInt(string(countStr), 10, 64)
+		if err != nil {
+			return hash, 0, ErrInvalidLine
+		}
+		count = n
+	}
+
+	return hash, count, nil
+}
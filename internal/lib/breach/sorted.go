@@ -0,0 +1,103 @@
+package breach
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// строка HIBP: 40 символов хеша, ":", счетчик и \r\n, с запасом на обрезанную строку перед ней
+const sortedReadChunk = 256
+
+// SortedFile ищет хеш бинарным поиском прямо в файле "Pwned Passwords (SHA-1, ordered by hash)",
+// в память файл не загружается
+type SortedFile struct {
+	f    *os.File
+	size int64
+}
+
+func newSortedFile(f *os.File) (*SortedFile, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &SortedFile{f: f, size: info.Size()}, nil
+}
+
+func (s *SortedFile) Contains(password string) (bool, error) {
+	hash := Hash(password)
+	target := bytes.ToUpper([]byte(hex.EncodeToString(hash[:])))
+
+	// ищем среди строк, которые начинаются в [lo, hi); lo всегда начало строки
+	lo, hi := int64(0), s.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, end, line, err := s.lineFrom(mid)
+		if err == io.EOF || (err == nil && start >= hi) {
+			hi = mid
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if len(line) < len(target) {
+			return false, ErrInvalidLine
+		}
+
+		switch bytes.Compare(bytes.ToUpper(line[:len(target)]), target) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = end
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+func (s *SortedFile) Close() error {
+	return s.f.Close()
+}
+
+// lineFrom возвращает первую строку, начинающуюся не раньше off, и смещение следующей за ней
+func (s *SortedFile) lineFrom(off int64) (int64, int64, []byte, error) {
+	readAt := off
+	if off > 0 {
+		readAt = off - 1 // по предыдущему байту видно, начинается ли строка ровно в off
+	}
+
+	buf := make([]byte, sortedReadChunk)
+	n, err := s.f.ReadAt(buf, readAt)
+	if err != nil && err != io.EOF {
+		return 0, 0, nil, err
+	}
+	buf = buf[:n]
+
+	start := 0
+	if off > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return 0, 0, nil, io.EOF
+		}
+		start = i + 1
+	}
+	if start >= len(buf) {
+		return 0, 0, nil, io.EOF
+	}
+
+	line := buf[start:]
+	length := len(line)
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+		length = i + 1
+	} else if readAt+int64(n) < s.size {
+		return 0, 0, nil, ErrInvalidLine // строка длиннее буфера
+	}
+
+	lineStart := readAt + int64(start)
+	return lineStart, lineStart + int64(length), bytes.TrimRight(line, "\r"), nil
+}
@@ -0,0 +1,88 @@
+// Package clientip определяет адрес клиента. X-Forwarded-For учитывается, только если
+// запрос пришёл от доверенного прокси, иначе его может подставить любой клиент.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New принимает адреса и подсети (CIDR) доверенных прокси; пустой список - XFF не учитывается
+func New(trustedProxies []string) (*Resolver, error) {
+	const op = "clientip.New"
+
+	r := &Resolver{}
+	for _, s := range trustedProxies {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return nil, fmt.Errorf("%s: invalid trusted proxy %q: %w", op, s, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+
+	return r, nil
+}
+
+// Resolve возвращает адрес клиента по адресу соединения (host:port или host) и значениям X-Forwarded-For.
+// Цепочка XFF читается справа налево, пока адреса принадлежат доверенным прокси:
+// первый недоверенный адрес и есть клиент.
+func (r *Resolver) Resolve(remoteAddr string, forwardedFor []string) string {
+	peer := hostOnly(remoteAddr)
+	if !r.isTrusted(peer) {
+		return peer
+	}
+
+	var hops []string
+	for _, v := range forwardedFor {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hostOnly(hops[i]))
+		if err != nil {
+			// мусор в заголовке: дальше цепочке верить нельзя
+			return client
+		}
+		client = addr.Unmap().String()
+		if !r.isTrusted(client) {
+			return client
+		}
+	}
+	return client
+}
+
+func (r *Resolver) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
@@ -0,0 +1,58 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var ErrCiphertext = errors.New("ciphertext is too short")
+
+// Encryptor шифрует секреты перед сохранением в базу (AES-256-GCM)
+type Encryptor struct {
+	aead cipher.AEAD
+}
+
+// New принимает ключ в base64, после декодирования он должен быть длиной 32 байта
+func New(keyBase64 string) (*Encryptor, error) {
+	const op = "encrypt.New"
+
+	key, err := base64.StdEncoding.DecodeString(keyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s: key must be 32 bytes, got %d", op, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Encryptor{aead: aead}, nil
+}
+
+// Encrypt возвращает nonce || ciphertext
+func (e *Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (e *Encryptor) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := e.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, ErrCiphertext
+	}
+	return e.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
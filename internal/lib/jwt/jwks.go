@@ -0,0 +1,60 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK публичный ключ в формате RFC 7517
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public parts of all non-retired asymmetric keys
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.PublicKeys() {
+		jwk := JWK{KeyID: key.ID, Algorithm: key.Method.Alg(), Use: "sig"}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = encode(public.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = public.Curve.Params().Name
+			jwk.X = encode(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = encode(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = encode(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
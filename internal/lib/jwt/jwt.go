@@ -2,44 +2,152 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
-var ErrInvalidToken = errors.New("invalid token")
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrWrongIssuer      = errors.New("token has wrong issuer")
+	ErrWrongAudience    = errors.New("token has wrong audience")
+	ErrBadSignature     = errors.New("token signature is invalid")
+)
 
 // Claims структура токена
 type Claims struct {
-	UserID int64  `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    int64  `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`       // семейство refresh токенов, с которым выдан токен
+	Scope     string `json:"scope,omitempty"`     // через пробел (RFC 9068), только у токенов OAuth клиентов; пустой - без ограничений
+	ClientID  string `json:"client_id,omitempty"` // OAuth клиент, которому выдан токен
 	jwt.RegisteredClaims
 }
 
-// GenerateToken создаёт JWT для Access Token с TTL
-func GenerateToken(userID int64, role, secret string, ttl time.Duration) (string, error) {
+// ClientSubjectPrefix начинает sub токенов client_credentials, за ним client_id
+const ClientSubjectPrefix = "client:"
+
+// Scopes возвращает scope токена списком
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// IsClient сообщает, что токен выдан самому OAuth клиенту (client_credentials), а не пользователю
+func (c *Claims) IsClient() bool {
+	return c.ClientID != "" && c.Subject == ClientSubjectPrefix+c.ClientID
+}
+
+// Options задают стандартные claims выдаваемых токенов и правила их проверки
+type Options struct {
+	Issuer     string
+	Audience   []string      // при проверке токен должен быть выдан хотя бы одной из аудиторий
+	Leeway     time.Duration // допустимое расхождение часов для exp, nbf и iat
+	Algorithms []string      // разрешённые алгоритмы; если пусто - алгоритмы ключей набора
+}
+
+// GenerateToken создаёт JWT для Access Token с TTL, подписанный активным ключом
+// tokenID попадает в jti и используется для отзыва токена, clientID и scopes задаются только для OAuth клиентов
+func (ks *KeySet) GenerateToken(userID int64, role, sessionID, tokenID, clientID string, scopes []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		Scope:     strings.Join(scopes, " "),
+		ClientID:  clientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    ks.opts.Issuer,
+			Subject:   strconv.FormatInt(userID, 10),
+			Audience:  ks.opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return ks.sign(claims)
+}
+
+// GenerateClientToken создаёт access токен сервиса (client_credentials): sub = client:<clientID>, user_id = 0
+func (ks *KeySet) GenerateClientToken(clientID, tokenID string, scopes []string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		Scope:    strings.Join(scopes, " "),
+		ClientID: clientID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        tokenID,
+			Issuer:    ks.opts.Issuer,
+			Subject:   ClientSubjectPrefix + clientID,
+			Audience:  ks.opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return ks.sign(claims)
 }
 
-// VerifyToken парсит и верифицирует JWT для Access Token
-func VerifyToken(tokenStr, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+// VerifyToken парсит и верифицирует JWT для Access Token любым действующим ключом.
+// Ошибка оборачивает одну из ErrMalformedToken, ErrTokenExpired, ErrTokenNotYetValid,
+// ErrWrongIssuer, ErrWrongAudience, ErrBadSignature или ErrInvalidToken.
+func (ks *KeySet) VerifyToken(tokenStr string) (*Claims, error) {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(ks.algorithms),
+		jwt.WithLeeway(ks.opts.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if ks.opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(ks.opts.Issuer))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, ks.keyFunc, parserOpts...)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
 		return nil, ErrInvalidToken
 	}
+
+	if len(ks.opts.Audience) > 0 && !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(ks.opts.Audience, aud)
+	}) {
+		return nil, ErrWrongAudience
+	}
+	// sub это либо user_id, либо client:<client_id> у токена без пользователя
+	if strings.HasPrefix(claims.Subject, ClientSubjectPrefix) {
+		if !claims.IsClient() || claims.UserID != 0 || claims.Role != "" || claims.SessionID != "" {
+			return nil, fmt.Errorf("%w: subject does not match client id", ErrInvalidToken)
+		}
+	} else if claims.Subject != "" && claims.Subject != strconv.FormatInt(claims.UserID, 10) {
+		return nil, fmt.Errorf("%w: subject does not match user id", ErrInvalidToken)
+	}
+
 	return claims, nil
 }
+
+// classify приводит ошибки библиотеки к ошибкам пакета
+func classify(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return fmt.Errorf("%w: %w", ErrTokenExpired, err)
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return fmt.Errorf("%w: %w", ErrTokenNotYetValid, err)
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return fmt.Errorf("%w: %w", ErrWrongIssuer, err)
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return fmt.Errorf("%w: %w", ErrWrongAudience, err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return fmt.Errorf("%w: %w", ErrBadSignature, err)
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+}
@@ -0,0 +1,268 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"slices"
+	"sort"
+)
+
+// LegacyKeyID is the key id of the shared HS256 secret, tokens signed with it have no kid header
+const LegacyKeyID = ""
+
+var (
+	ErrUnknownKey    = errors.New("unknown signing key")
+	ErrKeyRetired    = errors.New("signing key is retired")
+	ErrNoSigningKey  = errors.New("active signing key is not configured")
+	ErrAlgorithm     = errors.New("unsupported signing algorithm")
+	ErrKeyMismatch   = errors.New("key does not match algorithm")
+	ErrNoKeyMaterial = errors.New("neither private nor public key file is set")
+)
+
+// KeySpec describes a key loaded from PEM files.
+// A key with only PublicKeyFile is verify-only; a retired key is kept but no longer accepted.
+type KeySpec struct {
+	ID             string
+	Algorithm      string // RS256, RS384, RS512, ES256, ES384, ES512, EdDSA
+	PrivateKeyFile string
+	PublicKeyFile  string
+	Retired        bool
+}
+
+// Key is a single signing/verification key of the keyset
+type Key struct {
+	ID       string
+	Method   jwt.SigningMethod
+	Private  crypto.PrivateKey // nil for verify-only keys
+	Public   crypto.PublicKey
+	Retired  bool
+	secret   []byte // only for legacy HS256
+	isLegacy bool
+}
+
+// KeySet holds all known keys; tokens are signed with the active key
+// and verified against any non-retired key, so keys can be rotated without logging users out:
+// add the new key, deploy, switch active key, retire the old one after AccessTTL.
+type KeySet struct {
+	keys       map[string]*Key
+	active     *Key
+	opts       Options
+	algorithms []string // allowed algorithms
+}
+
+// NewKeySet loads keys from PEM files. legacySecret (may be empty) is the shared HS256 secret
+// used before asymmetric keys; without specs it is also the active key.
+func NewKeySet(specs []KeySpec, activeKeyID string, legacySecret string, opts Options) (*KeySet, error) {
+	const op = "jwt.NewKeySet"
+
+	ks := &KeySet{keys: make(map[string]*Key), opts: opts}
+
+	if legacySecret != "" {
+		ks.keys[LegacyKeyID] = &Key{
+			ID:       LegacyKeyID,
+			Method:   jwt.SigningMethodHS256,
+			secret:   []byte(legacySecret),
+			isLegacy: true,
+		}
+	}
+
+	for _, spec := range specs {
+		key, err := loadKey(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, spec.ID, err)
+		}
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("%s: duplicate key id %q", op, key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	if len(specs) == 0 && legacySecret != "" {
+		activeKeyID = LegacyKeyID
+	}
+
+	active, ok := ks.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoSigningKey)
+	}
+	if active.Retired {
+		return nil, fmt.Errorf("%s: %w", op, ErrKeyRetired)
+	}
+	if active.Private == nil && !active.isLegacy {
+		return nil, fmt.Errorf("%s: active key %q has no private key", op, activeKeyID)
+	}
+	ks.active = active
+
+	ks.algorithms = opts.Algorithms
+	if len(ks.algorithms) == 0 {
+		for _, key := range ks.keys {
+			if !slices.Contains(ks.algorithms, key.Method.Alg()) {
+				ks.algorithms = append(ks.algorithms, key.Method.Alg())
+			}
+		}
+	}
+	if !slices.Contains(ks.algorithms, active.Method.Alg()) {
+		return nil, fmt.Errorf("%s: active key algorithm %s is not allowed", op, active.Method.Alg())
+	}
+
+	return ks, nil
+}
+
+// ActiveKey returns the key new tokens are signed with
+func (ks *KeySet) ActiveKey() *Key {
+	return ks.active
+}
+
+// PublicKeys returns non-retired asymmetric keys ordered by kid
+func (ks *KeySet) PublicKeys() []*Key {
+	keys := make([]*Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		if key.isLegacy || key.Retired {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	if ks.active.isLegacy {
+		return token.SignedString(ks.active.secret)
+	}
+
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Private)
+}
+
+// keyFunc picks the verification key by kid and pins the algorithm to the key's one
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if key.Retired {
+		return nil, ErrKeyRetired
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, ErrAlgorithm
+	}
+
+	if key.isLegacy {
+		return key.secret, nil
+	}
+	return key.Public, nil
+}
+
+func loadKey(spec KeySpec) (*Key, error) {
+	if spec.ID == LegacyKeyID {
+		return nil, errors.New("kid is empty")
+	}
+
+	method := jwt.GetSigningMethod(spec.Algorithm)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+	default:
+		return nil, ErrAlgorithm
+	}
+
+	key := &Key{ID: spec.ID, Method: method, Retired: spec.Retired}
+
+	switch {
+	case spec.PrivateKeyFile != "":
+		pemBytes, err := os.ReadFile(spec.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := parsePrivateKey(method, pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case spec.PublicKeyFile != "":
+		pemBytes, err := os.ReadFile(spec.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		public, err := parsePublicKey(method, pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = public
+	default:
+		return nil, ErrNoKeyMaterial
+	}
+
+	if err := checkCurve(method, key.Public); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func parsePrivateKey(method jwt.SigningMethod, pemBytes []byte) (crypto.Signer, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		return jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPrivateKeyFromPEM(pemBytes)
+	case *jwt.SigningMethodEd25519:
+		key, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, ErrKeyMismatch
+		}
+		return signer, nil
+	}
+	return nil, ErrAlgorithm
+}
+
+func parsePublicKey(method jwt.SigningMethod, pemBytes []byte) (crypto.PublicKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		return jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPublicKeyFromPEM(pemBytes)
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPublicKeyFromPEM(pemBytes)
+	}
+	return nil, ErrAlgorithm
+}
+
+// checkCurve makes sure ES256/384/512 keys use the matching curve
+func checkCurve(method jwt.SigningMethod, public crypto.PublicKey) error {
+	switch m := method.(type) {
+	case *jwt.SigningMethodECDSA:
+		key, ok := public.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrKeyMismatch
+		}
+		want := map[int]elliptic.Curve{256: elliptic.P256(), 384: elliptic.P384(), 521: elliptic.P521()}[m.CurveBits]
+		if key.Curve != want {
+			return ErrKeyMismatch
+		}
+	case *jwt.SigningMethodRSA:
+		if _, ok := public.(*rsa.PublicKey); !ok {
+			return ErrKeyMismatch
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := public.(ed25519.PublicKey); !ok {
+			return ErrKeyMismatch
+		}
+	}
+	return nil
+}
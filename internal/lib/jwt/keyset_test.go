@@ -0,0 +1,301 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer       = "https://auth.example.com"
+	testAudience     = "api"
+	testLegacySecret = "legacy-secret"
+)
+
+type testKeys struct {
+	active  *ecdsa.PrivateKey // k1, ES256
+	rsa     *rsa.PrivateKey   // k2, RS256, verify-only
+	retired *ecdsa.PrivateKey // old, ES256, retired
+}
+
+// writePEM сохраняет ключ в PKCS#8 (приватный) или PKIX (публичный), как его кладут в конфиг
+func writePEM(t *testing.T, dir, name string, key any) string {
+	t.Helper()
+
+	var block *pem.Block
+	switch k := key.(type) {
+	case crypto.Signer:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			t.Fatalf("marshal private key: %v", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			t.Fatalf("marshal public key: %v", err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func newTestKeySet(t *testing.T, opts Options) (*KeySet, testKeys) {
+	t.Helper()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiredKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	specs := []KeySpec{
+		{ID: "k1", Algorithm: "ES256", PrivateKeyFile: writePEM(t, dir, "k1.pem", ecKey)},
+		{ID: "k2", Algorithm: "RS256", PublicKeyFile: writePEM(t, dir, "k2.pub.pem", &rsaKey.PublicKey)},
+		{ID: "old", Algorithm: "ES256", PublicKeyFile: writePEM(t, dir, "old.pub.pem", &retiredKey.PublicKey), Retired: true},
+	}
+
+	opts.Issuer = testIssuer
+	opts.Audience = []string{testAudience}
+	ks, err := NewKeySet(specs, "k1", testLegacySecret, opts)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return ks, testKeys{active: ecKey, rsa: rsaKey, retired: retiredKey}
+}
+
+func testClaims() Claims {
+	now := time.Now()
+	return Claims{
+		UserID: 42,
+		Role:   "buyer",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			Issuer:    testIssuer,
+			Subject:   "42",
+			Audience:  jwt.ClaimStrings{testAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+}
+
+// signToken подписывает claims как угодно, в том числе так, как честный эмитент не стал бы
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any, claims Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyToken(t *testing.T) {
+	ks, keys := newTestKeySet(t, Options{})
+
+	activePublic, err := x509.MarshalPKIXPublicKey(&keys.active.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modify := func(f func(c *Claims)) Claims {
+		c := testClaims()
+		f(&c)
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "active key",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, testClaims()),
+		},
+		{
+			name:  "other non-retired key",
+			token: signToken(t, jwt.SigningMethodRS256, "k2", keys.rsa, testClaims()),
+		},
+		{
+			name:  "legacy HS256 without kid",
+			token: signToken(t, jwt.SigningMethodHS256, "", []byte(testLegacySecret), testClaims()),
+		},
+		{
+			name:    "legacy HS256 with wrong secret",
+			token:   signToken(t, jwt.SigningMethodHS256, "", []byte("other-secret"), testClaims()),
+			wantErr: ErrBadSignature,
+		},
+		{
+			// alg confusion: публичный ключ ES256 как секрет HMAC
+			name:    "HS256 with kid of an asymmetric key",
+			token:   signToken(t, jwt.SigningMethodHS256, "k1", activePublic, testClaims()),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "algorithm differs from the key algorithm",
+			token:   signToken(t, jwt.SigningMethodRS256, "k1", keys.rsa, testClaims()),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "unknown kid",
+			token:   signToken(t, jwt.SigningMethodES256, "k3", keys.active, testClaims()),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "retired key",
+			token:   signToken(t, jwt.SigningMethodES256, "old", keys.retired, testClaims()),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "alg none",
+			token:   signToken(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, testClaims()),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name: "expired",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, modify(func(c *Claims) {
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			})),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "without exp",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, modify(func(c *Claims) {
+				c.ExpiresAt = nil
+			})),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "wrong issuer",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, modify(func(c *Claims) {
+				c.Issuer = "https://evil.example.com"
+			})),
+			wantErr: ErrWrongIssuer,
+		},
+		{
+			name: "wrong audience",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, modify(func(c *Claims) {
+				c.Audience = jwt.ClaimStrings{"other"}
+			})),
+			wantErr: ErrWrongAudience,
+		},
+		{
+			name: "subject does not match user id",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, modify(func(c *Claims) {
+				c.Subject = "7"
+			})),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "client subject with user claims",
+			token: signToken(t, jwt.SigningMethodES256, "k1", keys.active, modify(func(c *Claims) {
+				c.ClientID = "svc"
+				c.Subject = ClientSubjectPrefix + "svc"
+			})),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "malformed",
+			token:   "not.a.jwt",
+			wantErr: ErrMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ks.VerifyToken(tt.token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("VerifyToken: %v", err)
+				}
+				if claims.UserID != 42 {
+					t.Errorf("user id = %d, want 42", claims.UserID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenAllowedAlgorithms(t *testing.T) {
+	// HS256 не в списке: legacy токены больше не принимаются, хотя секрет ещё задан
+	ks, keys := newTestKeySet(t, Options{Algorithms: []string{"ES256", "RS256"}})
+
+	legacy := signToken(t, jwt.SigningMethodHS256, "", []byte(testLegacySecret), testClaims())
+	if _, err := ks.VerifyToken(legacy); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("legacy token: err = %v, want %v", err, ErrBadSignature)
+	}
+
+	current := signToken(t, jwt.SigningMethodES256, "k1", keys.active, testClaims())
+	if _, err := ks.VerifyToken(current); err != nil {
+		t.Fatalf("current token: %v", err)
+	}
+}
+
+func TestGenerateTokenRoundTrip(t *testing.T) {
+	ks, _ := newTestKeySet(t, Options{})
+
+	token, err := ks.GenerateToken(42, "admin", "session", "jti", "", nil, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "k1" {
+		t.Errorf("kid = %v, want k1", kid)
+	}
+
+	claims, err := ks.VerifyToken(token)
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if claims.UserID != 42 || claims.Role != "admin" || claims.SessionID != "session" || claims.ID != "jti" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	clientToken, err := ks.GenerateClientToken("svc", "jti2", []string{"introspect"}, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateClientToken: %v", err)
+	}
+	claims, err = ks.VerifyToken(clientToken)
+	if err != nil {
+		t.Fatalf("VerifyToken client token: %v", err)
+	}
+	if !claims.IsClient() || claims.ClientID != "svc" {
+		t.Errorf("unexpected client claims %+v", claims)
+	}
+}
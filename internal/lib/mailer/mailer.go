@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidHeader = errors.New("header contains line break")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string // text/plain
+}
+
+// Mailer sends transactional emails (verification links, password reset ...)
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP sends mail through an SMTP relay, STARTTLS is used when the server supports it
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	return &SMTP{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	const op = "mailer.SMTP.Send"
+
+	raw, err := build(m.from, msg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	// net/smtp не принимает контекст, поэтому только не начинаем отправку после отмены
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{msg.To}, raw); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// File writes every message as .eml file into dir, for local development
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir, from string) *File {
+	return &File{dir: dir, from: from}
+}
+
+func (m *File) Send(_ context.Context, msg Message) error {
+	const op = "mailer.File.Send"
+
+	raw, err := build(m.from, msg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	if err := os.WriteFile(filepath.Join(m.dir, name), raw, 0o600); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Log only writes messages to the log, for local development and tests
+type Log struct {
+	log *slog.Logger
+}
+
+func NewLog(log *slog.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (m *Log) Send(_ context.Context, msg Message) error {
+	m.log.Info("email sent",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
+
+// build renders RFC 5322 message
+func build(from string, msg Message) ([]byte, error) {
+	for _, header := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String()), nil
+}
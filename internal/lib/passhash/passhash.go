@@ -0,0 +1,179 @@
+// Package passhash хеширует пароли argon2id или bcrypt и проверяет хеши обоих форматов,
+// чтобы старые bcrypt хеши продолжали работать после смены алгоритма
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// BcryptMaxPasswordBytes bcrypt не хеширует пароли длиннее, их нужно отклонять политикой паролей
+const BcryptMaxPasswordBytes = 72
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrInvalidHash      = errors.New("invalid password hash")
+)
+
+// PasswordHasher хеширует новые пароли текущим алгоритмом и определяет алгоритм проверяемого хеша по префиксу
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (bool, error)
+	// NeedsRehash true, если хеш сделан другим алгоритмом или с другими параметрами
+	NeedsRehash(encoded string) bool
+}
+
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type Options struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+type Hasher struct {
+	opts Options
+}
+
+func New(opts Options) (*Hasher, error) {
+	const op = "passhash.New"
+
+	switch opts.Algorithm {
+	case Argon2id:
+		if opts.Argon2.Memory == 0 || opts.Argon2.Iterations == 0 || opts.Argon2.Parallelism == 0 {
+			return nil, fmt.Errorf("%s: argon2id parameters must be positive", op)
+		}
+		if opts.Argon2.SaltLength == 0 {
+			opts.Argon2.SaltLength = 16
+		}
+		if opts.Argon2.KeyLength == 0 {
+			opts.Argon2.KeyLength = 32
+		}
+	case Bcrypt:
+		if opts.BcryptCost < bcrypt.MinCost || opts.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("%s: bcrypt cost must be between %d and %d", op, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownAlgorithm, opts.Algorithm)
+	}
+
+	return &Hasher{opts: opts}, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.opts.Algorithm == Bcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.opts.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	p := h.opts.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return encodeArgon2(p, salt, key), nil
+}
+
+func (h *Hasher) Verify(password string, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$"+Argon2id+"$"):
+		p, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return false, ErrInvalidHash
+}
+
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if h.opts.Algorithm == Bcrypt {
+		if !isBcrypt(encoded) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.opts.BcryptCost
+	}
+
+	p, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return true
+	}
+	want := h.opts.Argon2
+	return p.Memory != want.Memory || p.Iterations != want.Iterations || p.Parallelism != want.Parallelism ||
+		uint32(len(salt)) != want.SaltLength || uint32(len(key)) != want.KeyLength
+}
+
+// PHC: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>, base64 без паддинга
+func encodeArgon2(p Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,208 @@
+// Package passpolicy проверяет новые пароли по настраиваемым правилам
+package passpolicy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Причины нарушений, отдаются клиенту в BadRequest.FieldViolation.Reason
+const (
+	ReasonTooShort       = "PASSWORD_TOO_SHORT"
+	ReasonTooLong        = "PASSWORD_TOO_LONG"
+	ReasonMissingUpper   = "PASSWORD_MISSING_UPPERCASE"
+	ReasonMissingLower   = "PASSWORD_MISSING_LOWERCASE"
+	ReasonMissingDigit   = "PASSWORD_MISSING_DIGIT"
+	ReasonMissingSymbol  = "PASSWORD_MISSING_SYMBOL"
+	ReasonPersonalInfo   = "PASSWORD_CONTAINS_PERSONAL_INFO"
+	ReasonCommonPassword = "PASSWORD_TOO_COMMON"
+	ReasonBreached       = "PASSWORD_BREACHED"
+)
+
+// более короткие части имени/email слишком часто совпадают случайно
+const minPersonalPartLength = 3
+
+type Options struct {
+	MinLength          int
+	MaxLength          int // 0 - без ограничения
+	MaxBytes           int // ограничение алгоритма хеширования (bcrypt - 72 байта), 0 - без ограничения
+	RequireUpper       bool
+	RequireLower       bool
+	RequireDigit       bool
+	RequireSymbol      bool
+	RejectPersonalInfo bool
+	BlocklistFile      string // один пароль на строку, # - комментарий
+}
+
+// UserInfo данные пользователя, которые не должны входить в пароль
+type UserInfo struct {
+	Username string
+	Email    string
+	FIO      string
+}
+
+type Violation struct {
+	Reason      string
+	Description string
+}
+
+// BreachCorpus локальная база утекших паролей, см. lib/breach
+type BreachCorpus interface {
+	Contains(password string) (bool, error)
+}
+
+type Policy struct {
+	opts      Options
+	blocklist map[string]struct{}
+	breached  BreachCorpus
+}
+
+// New загружает blocklist; breached может быть nil, тогда проверка по утечкам отключена
+func New(opts Options, breached BreachCorpus) (*Policy, error) {
+	const op = "passpolicy.New"
+
+	p := &Policy{opts: opts, breached: breached}
+	if opts.BlocklistFile != "" {
+		blocklist, err := loadBlocklist(opts.BlocklistFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.blocklist = blocklist
+	}
+
+	return p, nil
+}
+
+// Check возвращает все нарушения сразу, чтобы клиент мог показать их вместе.
+// Ошибка только при сбое чтения базы утечек.
+func (p *Policy) Check(password string, user UserInfo) ([]Violation, error) {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.opts.MinLength {
+		violations = append(violations, Violation{
+			Reason:      ReasonTooShort,
+			Description: fmt.Sprintf("must be at least %d characters long", p.opts.MinLength),
+		})
+	}
+	if p.opts.MaxLength > 0 && length > p.opts.MaxLength {
+		violations = append(violations, Violation{
+			Reason:      ReasonTooLong,
+			Description: fmt.Sprintf("must be at most %d characters long", p.opts.MaxLength),
+		})
+	}
+
+	// bcrypt не принимает пароли длиннее 72 байт, кириллица занимает по 2 байта на символ
+	if p.opts.MaxBytes > 0 && len(password) > p.opts.MaxBytes && (p.opts.MaxLength <= 0 || length <= p.opts.MaxLength) {
+		violations = append(violations, Violation{
+			Reason:      ReasonTooLong,
+			Description: fmt.Sprintf("must be at most %d bytes long", p.opts.MaxBytes),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if p.opts.RequireUpper && !hasUpper {
+		violations = append(violations, Violation{Reason: ReasonMissingUpper, Description: "must contain an uppercase letter"})
+	}
+	if p.opts.RequireLower && !hasLower {
+		violations = append(violations, Violation{Reason: ReasonMissingLower, Description: "must contain a lowercase letter"})
+	}
+	if p.opts.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Reason: ReasonMissingDigit, Description: "must contain a digit"})
+	}
+	if p.opts.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Reason: ReasonMissingSymbol, Description: "must contain a symbol"})
+	}
+
+	lower := strings.ToLower(password)
+	if p.opts.RejectPersonalInfo && containsPersonalInfo(lower, user) {
+		violations = append(violations, Violation{
+			Reason:      ReasonPersonalInfo,
+			Description: "must not contain your username, email or name",
+		})
+	}
+	if _, ok := p.blocklist[lower]; ok {
+		violations = append(violations, Violation{Reason: ReasonCommonPassword, Description: "is too common"})
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			return nil, fmt.Errorf("passpolicy.Check: %w", err)
+		}
+		if breached {
+			violations = append(violations, Violation{
+				Reason:      ReasonBreached,
+				Description: "has appeared in a known data breach",
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+func containsPersonalInfo(password string, user UserInfo) bool {
+	local, _, _ := strings.Cut(user.Email, "@")
+
+	parts := []string{user.Username, local}
+	parts = append(parts, splitWords(user.Username)...)
+	parts = append(parts, splitWords(local)...)
+	parts = append(parts, splitWords(user.FIO)...)
+
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if utf8.RuneCountInString(part) < minPersonalPartLength {
+			continue
+		}
+		if strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWords делит строку по всему, что не буква и не цифра: "ivan.petrov_92" -> ivan, petrov, 92
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func loadBlocklist(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	blocklist := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return blocklist, nil
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// Memory хранит bucket'ы в памяти, лимиты действуют на каждую реплику отдельно
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // после этого момента bucket снова полный и его можно удалить
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(refillTime(b.tokens, limit))
+
+	if !allowed {
+		return false, retryAfter(b.tokens, limit), nil
+	}
+	return true, 0, nil
+}
+
+// sweep удаляет полные bucket'ы, чтобы map не росла от разовых клиентов
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if now.After(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Package ratelimit ограничивает частоту запросов token bucket'ами: в памяти процесса
+// или в Postgres, если реплик несколько
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit: Rate токенов в секунду, не больше Burst подряд. Rate <= 0 - без ограничений
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Limiter забирает токен из bucket'а key. Если токена нет, возвращает false и через сколько он появится
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// refillTime время, за которое bucket наполнится с tokens до burst, после него bucket можно забыть
+func refillTime(tokens float64, limit Limit) time.Duration {
+	return time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+}
+
+// retryAfter время до появления целого токена
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	return time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Store атомарно пополняет bucket и забирает из него токен, возвращает остаток
+type Store interface {
+	TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (bool, float64, error)
+	DeleteIdleRateLimitBuckets(ctx context.Context, idleFor time.Duration) error
+}
+
+// Shared держит bucket'ы в общем хранилище, лимит действует на все реплики вместе
+type Shared struct {
+	store       Store
+	idleFor     time.Duration
+	lastCleanup atomic.Int64
+}
+
+// NewShared: bucket'ы без запросов дольше idleFor удаляются, idleFor должен быть больше времени наполнения
+// самого медленного bucket'а
+func NewShared(store Store, idleFor time.Duration) *Shared {
+	s := &Shared{store: store, idleFor: idleFor}
+	s.lastCleanup.Store(time.Now().UnixNano())
+	return s
+}
+
+func (s *Shared) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	s.cleanup(ctx)
+
+	allowed, tokens, err := s.store.TakeRateLimitToken(ctx, key, limit.Rate, limit.Burst)
+	if err != nil {
+		return false, 0, err
+	}
+	if !allowed {
+		return false, retryAfter(tokens, limit), nil
+	}
+	return true, 0, nil
+}
+
+// cleanup раз в sweepInterval удаляет старые bucket'ы; достаточно, чтобы это сделал один запрос одной реплики
+func (s *Shared) cleanup(ctx context.Context) {
+	last := s.lastCleanup.Load()
+	now := time.Now().UnixNano()
+	if time.Duration(now-last) < sweepInterval || !s.lastCleanup.CompareAndSwap(last, now) {
+		return
+	}
+
+	// ошибка не мешает лимитам, попробуем в следующий раз
+	_ = s.store.DeleteIdleRateLimitBuckets(ctx, s.idleFor)
+}
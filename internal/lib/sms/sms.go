@@ -0,0 +1,58 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// SMSSender delivers short text messages, real providers are plugged in behind it
+type SMSSender interface {
+	Send(ctx context.Context, phoneNumber string, text string) error
+}
+
+// Console writes messages to the log, for local development
+type Console struct {
+	log *slog.Logger
+}
+
+func NewConsole(log *slog.Logger) *Console {
+	return &Console{log: log}
+}
+
+func (s *Console) Send(_ context.Context, phoneNumber string, text string) error {
+	s.log.Info("sms sent", slog.String("to", phoneNumber), slog.String("text", text))
+	return nil
+}
+
+// File appends messages to a file, one line per message, for local development and tests
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (s *File) Send(_ context.Context, phoneNumber string, text string) error {
+	const op = "sms.File.Send"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s\t%s\t%q\n", time.Now().Format(time.RFC3339), phoneNumber, text); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package tokenhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Hash возвращает HMAC-SHA256 от токена в hex, в базе хранится только он
+func Hash(token, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
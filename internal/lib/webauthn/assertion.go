@@ -0,0 +1,41 @@
+package webauthn
+
+// AssertionResult is the authenticator state after a successful assertion
+type AssertionResult struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// VerifyAssertion checks the result of navigator.credentials.get() made with the stored credential.
+// A sign count that did not increase means the authenticator may be cloned (ErrSignCountRollback),
+// authenticators that always report zero (synced passkeys) are allowed.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, credential Credential, clientDataJSON, authenticatorDataRaw, signature []byte) (AssertionResult, error) {
+	if err := rp.verifyClientData(clientDataJSON, typeGet, challenge); err != nil {
+		return AssertionResult{}, err
+	}
+
+	authData, err := parseAuthenticatorData(authenticatorDataRaw)
+	if err != nil {
+		return AssertionResult{}, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return AssertionResult{}, err
+	}
+
+	pub, alg, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return AssertionResult{}, err
+	}
+	if err := verifySignature(pub, alg, signedData(authData.raw, clientDataJSON), signature); err != nil {
+		return AssertionResult{}, err
+	}
+
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return AssertionResult{}, ErrSignCountRollback
+	}
+
+	return AssertionResult{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"slices"
+)
+
+// id-fido-gen-ce-aaguid, расширение сертификата с AAGUID аутентификатора
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+type attestationObject struct {
+	Format   string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+type packedStatement struct {
+	Alg int64    `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c,omitempty"`
+}
+
+// VerifyRegistration checks the result of navigator.credentials.create() against the issued challenge.
+// Attestation certificates are checked for validity of the signature only, trust in the
+// authenticator model is not evaluated.
+func (rp *RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestation []byte) (Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, typeCreate, challenge); err != nil {
+		return Credential{}, err
+	}
+
+	var obj attestationObject
+	if err := cbor.Unmarshal(attestation, &obj); err != nil {
+		return Credential{}, fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+	}
+
+	authData, err := parseAuthenticatorData(obj.AuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return Credential{}, err
+	}
+	if authData.credentialID == nil {
+		return Credential{}, fmt.Errorf("%w: no attested credential data", ErrInvalidAuthData)
+	}
+
+	pub, alg, err := parsePublicKey(authData.publicKey)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	switch obj.Format {
+	case "none":
+		var stmt map[string]any
+		if err := cbor.Unmarshal(obj.AttStmt, &stmt); err != nil || len(stmt) != 0 {
+			return Credential{}, fmt.Errorf("%w: none attestation must have empty statement", ErrInvalidAttestation)
+		}
+	case "packed":
+		var stmt packedStatement
+		if err := cbor.Unmarshal(obj.AttStmt, &stmt); err != nil {
+			return Credential{}, fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+		}
+		if err := verifyPacked(stmt, authData, clientDataJSON, pub, alg); err != nil {
+			return Credential{}, err
+		}
+	default:
+		return Credential{}, fmt.Errorf("%w: %s", ErrUnsupportedFormat, obj.Format)
+	}
+
+	return Credential{
+		ID:           slices.Clone(authData.credentialID),
+		PublicKey:    slices.Clone(authData.publicKey),
+		Algorithm:    alg,
+		SignCount:    authData.signCount,
+		AAGUID:       slices.Clone(authData.aaguid),
+		Format:       obj.Format,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// verifyPacked implements packed attestation (WebAuthn §8.2): full with x5c or self attestation
+func verifyPacked(stmt packedStatement, authData authenticatorData, clientDataJSON []byte, credentialKey any, credentialAlg int64) error {
+	data := signedData(authData.raw, clientDataJSON)
+
+	if len(stmt.X5C) == 0 {
+		// self attestation: подписано самим ключом учётных данных
+		if stmt.Alg != credentialAlg {
+			return fmt.Errorf("%w: alg does not match credential key", ErrInvalidAttestation)
+		}
+		if err := verifySignature(credentialKey, stmt.Alg, data, stmt.Sig); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+		}
+		return nil
+	}
+
+	cert, err := x509.ParseCertificate(stmt.X5C[0])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+	}
+	if err := verifySignature(cert.PublicKey, stmt.Alg, data, stmt.Sig); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+	}
+
+	// требования к сертификату из §8.2.1
+	if cert.Version != 3 || cert.IsCA {
+		return fmt.Errorf("%w: invalid attestation certificate", ErrInvalidAttestation)
+	}
+	if !slices.Contains(cert.Subject.OrganizationalUnit, "Authenticator Attestation") {
+		return fmt.Errorf("%w: invalid attestation certificate subject", ErrInvalidAttestation)
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidFIDOAAGUID) {
+			continue
+		}
+		if ext.Critical {
+			return fmt.Errorf("%w: aaguid extension must not be critical", ErrInvalidAttestation)
+		}
+		var aaguid []byte
+		if _, err := asn1.Unmarshal(ext.Value, &aaguid); err != nil || !bytes.Equal(aaguid, authData.aaguid) {
+			return fmt.Errorf("%w: aaguid does not match certificate", ErrInvalidAttestation)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"math/big"
+)
+
+// COSE алгоритмы, которые умеет проверять сервер
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// Algorithms in order of preference, for pubKeyCredParams
+var Algorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// parsePublicKey decodes COSE_Key (RFC 9053) into a Go public key
+func parsePublicKey(coseKey []byte) (crypto.PublicKey, int64, error) {
+	var fields map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(coseKey, &fields); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, err)
+	}
+
+	var kty, alg int64
+	if err := decodeField(fields, coseKeyType, &kty); err != nil {
+		return nil, 0, err
+	}
+	if err := decodeField(fields, coseAlgorithm, &alg); err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		var crv int64
+		var x, y []byte
+		if err := decodeField(fields, -1, &crv); err != nil {
+			return nil, 0, err
+		}
+		if err := decodeField(fields, -2, &x); err != nil {
+			return nil, 0, err
+		}
+		if err := decodeField(fields, -3, &y); err != nil {
+			return nil, 0, err
+		}
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, fmt.Errorf("%w: invalid P-256 key", ErrUnsupportedAlgorithm)
+		}
+
+		// проверяем, что точка лежит на кривой
+		point := append([]byte{4}, append(x, y...)...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, err)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return pub, alg, nil
+
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		var crv int64
+		var x []byte
+		if err := decodeField(fields, -1, &crv); err != nil {
+			return nil, 0, err
+		}
+		if err := decodeField(fields, -2, &x); err != nil {
+			return nil, 0, err
+		}
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, fmt.Errorf("%w: invalid Ed25519 key", ErrUnsupportedAlgorithm)
+		}
+		return ed25519.PublicKey(x), alg, nil
+
+	case kty == coseKtyRSA && alg == AlgRS256:
+		var n, e []byte
+		if err := decodeField(fields, -1, &n); err != nil {
+			return nil, 0, err
+		}
+		if err := decodeField(fields, -2, &e); err != nil {
+			return nil, 0, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 {
+			return nil, 0, fmt.Errorf("%w: invalid RSA key", ErrUnsupportedAlgorithm)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, alg, nil
+	}
+
+	return nil, 0, fmt.Errorf("%w: kty %d alg %d", ErrUnsupportedAlgorithm, kty, alg)
+}
+
+func decodeField(fields map[int]cbor.RawMessage, label int, v any) error {
+	raw, ok := fields[label]
+	if !ok {
+		return fmt.Errorf("%w: missing COSE key parameter %d", ErrUnsupportedAlgorithm, label)
+	}
+	if err := cbor.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: COSE key parameter %d: %v", ErrUnsupportedAlgorithm, label, err)
+	}
+	return nil
+}
+
+// verifySignature checks sig over data made with alg by the owner of pub
+func verifySignature(pub crypto.PublicKey, alg int64, data, sig []byte) error {
+	switch alg {
+	case AlgES256:
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok || key.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: key does not match ES256", ErrUnsupportedAlgorithm)
+		}
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgEdDSA:
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match EdDSA", ErrUnsupportedAlgorithm)
+		}
+		if !ed25519.Verify(key, data, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgRS256:
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match RS256", ErrUnsupportedAlgorithm)
+		}
+		digest := sha256.Sum256(data)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg)
+}
@@ -0,0 +1,191 @@
+// Package webauthn implements the server side of WebAuthn ceremonies needed for passkeys:
+// registration with "none" and "packed" attestation and assertion verification.
+// Functions work with raw bytes from the client, so a software authenticator is enough to drive them.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidClientData    = errors.New("invalid client data")
+	ErrInvalidAuthData      = errors.New("invalid authenticator data")
+	ErrChallengeMismatch    = errors.New("challenge mismatch")
+	ErrOriginMismatch       = errors.New("origin is not allowed")
+	ErrRPIDMismatch         = errors.New("rp id hash mismatch")
+	ErrUserNotPresent       = errors.New("user presence flag is not set")
+	ErrUserNotVerified      = errors.New("user verification is required")
+	ErrUnsupportedFormat    = errors.New("unsupported attestation format")
+	ErrInvalidAttestation   = errors.New("invalid attestation statement")
+	ErrUnsupportedAlgorithm = errors.New("unsupported public key algorithm")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrSignCountRollback    = errors.New("sign count did not increase, authenticator may be cloned")
+)
+
+const (
+	challengeSize   = 32
+	maxCredentialID = 1023
+
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// RelyingParty holds the server settings the ceremonies are checked against
+type RelyingParty struct {
+	ID                      string // домен, например example.com
+	Name                    string
+	Origins                 []string // разрешённые origin клиента, например https://example.com
+	Timeout                 time.Duration
+	RequireUserVerification bool
+}
+
+// Credential is a registered public key credential
+type Credential struct {
+	ID           []byte
+	PublicKey    []byte // COSE_Key как прислал аутентификатор
+	Algorithm    int64  // COSE алгоритм
+	SignCount    uint32
+	AAGUID       []byte
+	Format       string // формат аттестации
+	UserVerified bool
+}
+
+// NewChallenge returns a random challenge for one ceremony
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// UserVerification returns the value for PublicKeyCredential options
+func (rp *RelyingParty) UserVerification() string {
+	if rp.RequireUserVerification {
+		return "required"
+	}
+	return "preferred"
+}
+
+type collectedClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ChallengeFromClientData extracts the challenge so the server can find the ceremony it belongs to.
+// The rest of client data is checked by VerifyRegistration and VerifyAssertion.
+func ChallengeFromClientData(clientDataJSON []byte) ([]byte, error) {
+	var data collectedClientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClientData, err)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClientData, err)
+	}
+	return challenge, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var data collectedClientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidClientData, err)
+	}
+
+	if data.Type != ceremony {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidClientData, data.Type)
+	}
+	if data.Challenge != base64.RawURLEncoding.EncodeToString(challenge) {
+		return ErrChallengeMismatch
+	}
+	if !slices.Contains(rp.Origins, data.Origin) {
+		return fmt.Errorf("%w: %s", ErrOriginMismatch, data.Origin)
+	}
+	return nil
+}
+
+type authenticatorData struct {
+	raw       []byte
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+
+	// заполнены только при регистрации (флаг AT)
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(raw []byte) (authenticatorData, error) {
+	if len(raw) < 37 {
+		return authenticatorData{}, fmt.Errorf("%w: too short", ErrInvalidAuthData)
+	}
+
+	data := authenticatorData{
+		raw:       raw,
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if data.flags&flagAttested == 0 {
+		return data, nil
+	}
+
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return authenticatorData{}, fmt.Errorf("%w: attested credential data is too short", ErrInvalidAuthData)
+	}
+	data.aaguid = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen > maxCredentialID || len(rest) < idLen {
+		return authenticatorData{}, fmt.Errorf("%w: invalid credential id length", ErrInvalidAuthData)
+	}
+	data.credentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	// после ключа могут идти расширения, поэтому читаем только первый CBOR объект
+	var key cbor.RawMessage
+	if _, err := cbor.UnmarshalFirst(rest, &key); err != nil {
+		return authenticatorData{}, fmt.Errorf("%w: %v", ErrInvalidAuthData, err)
+	}
+	data.publicKey = key
+
+	return data, nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(data authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return ErrRPIDMismatch
+	}
+	if data.flags&flagUserPresent == 0 {
+		return ErrUserNotPresent
+	}
+	if rp.RequireUserVerification && data.flags&flagUserVerified == 0 {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+// signedData is what both attestation and assertion signatures cover
+func signedData(authData, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	return append(slices.Clone(authData), clientDataHash[:]...)
+}
@@ -0,0 +1,361 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/fxamacker/cbor/v2"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+func testRP() *RelyingParty {
+	return &RelyingParty{
+		ID:                      testRPID,
+		Name:                    "Example",
+		Origins:                 []string{testOrigin},
+		Timeout:                 time.Minute,
+		RequireUserVerification: true,
+	}
+}
+
+// softAuthenticator делает то же, что браузер и аутентификатор: собирает client data,
+// authenticator data и подписывает их ключом ES256
+type softAuthenticator struct {
+	t            *testing.T
+	rpID         string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	aaguid       []byte
+	signCount    uint32
+	noCounter    bool // как синхронизируемые passkeys, всегда присылает 0
+}
+
+func newSoftAuthenticator(t *testing.T, rpID string) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{
+		t:            t,
+		rpID:         rpID,
+		key:          key,
+		credentialID: randomBytes(t, 32),
+		aaguid:       randomBytes(t, 16),
+	}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	a.t.Helper()
+
+	// ключи карты в каноническом порядке, чтобы ключ можно было сравнить побайтно
+	enc, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	key, err := enc.Marshal(map[int]any{
+		coseKeyType:   coseKtyEC2,
+		coseAlgorithm: AlgES256,
+		-1:            coseCrvP256,
+		-2:            a.key.X.FillBytes(make([]byte, 32)),
+		-3:            a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return key
+}
+
+func (a *softAuthenticator) authenticatorData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := byte(flagUserPresent | flagUserVerified)
+	if attested {
+		flags |= flagAttested
+	}
+
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, a.aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+// register возвращает ответ navigator.credentials.create(); attStmt nil - формат none
+func (a *softAuthenticator) register(challenge []byte, origin, format string, attStmt func(signed []byte) map[string]any) (clientDataJSON, attestation []byte) {
+	a.t.Helper()
+
+	clientDataJSON = clientData(a.t, typeCreate, challenge, origin)
+	authData := a.authenticatorData(true)
+
+	stmt := map[string]any{}
+	if attStmt != nil {
+		stmt = attStmt(signedData(authData, clientDataJSON))
+	}
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      format,
+		"attStmt":  stmt,
+		"authData": authData,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return clientDataJSON, attestation
+}
+
+// assert возвращает ответ navigator.credentials.get(), счетчик растет на каждую подпись
+func (a *softAuthenticator) assert(challenge []byte, origin string) (clientDataJSON, authData, signature []byte) {
+	a.t.Helper()
+
+	if !a.noCounter {
+		a.signCount++
+	}
+	clientDataJSON = clientData(a.t, typeGet, challenge, origin)
+	authData = a.authenticatorData(false)
+	return clientDataJSON, authData, sign(a.t, a.key, signedData(authData, clientDataJSON))
+}
+
+func clientData(t *testing.T, ceremony string, challenge []byte, origin string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(collectedClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	t.Helper()
+
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func newChallenge(t *testing.T) []byte {
+	t.Helper()
+
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+// attestationCertificate выпускает самоподписанный сертификат аттестации по требованиям WebAuthn §8.2.1
+func attestationCertificate(t *testing.T, key *ecdsa.PrivateKey, aaguid []byte) []byte {
+	t.Helper()
+
+	aaguidExt, err := asn1.Marshal(aaguid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"Test Authenticator Vendor"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Test Authenticator",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions:       []pkix.Extension{{Id: oidFIDOAAGUID, Value: aaguidExt}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestVerifyRegistrationNone(t *testing.T) {
+	rp := testRP()
+	authenticator := newSoftAuthenticator(t, testRPID)
+	challenge := newChallenge(t)
+
+	clientDataJSON, attestation := authenticator.register(challenge, testOrigin, "none", nil)
+	credential, err := rp.VerifyRegistration(challenge, clientDataJSON, attestation)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+
+	if !bytes.Equal(credential.ID, authenticator.credentialID) {
+		t.Errorf("credential id = %x, want %x", credential.ID, authenticator.credentialID)
+	}
+	if !bytes.Equal(credential.PublicKey, authenticator.coseKey()) {
+		t.Error("public key does not match the authenticator key")
+	}
+	if !bytes.Equal(credential.AAGUID, authenticator.aaguid) {
+		t.Errorf("aaguid = %x, want %x", credential.AAGUID, authenticator.aaguid)
+	}
+	if credential.Algorithm != AlgES256 || credential.Format != "none" || !credential.UserVerified {
+		t.Errorf("unexpected credential %+v", credential)
+	}
+}
+
+func TestVerifyRegistrationPacked(t *testing.T) {
+	rp := testRP()
+
+	t.Run("self attestation", func(t *testing.T) {
+		authenticator := newSoftAuthenticator(t, testRPID)
+		challenge := newChallenge(t)
+
+		clientDataJSON, attestation := authenticator.register(challenge, testOrigin, "packed", func(signed []byte) map[string]any {
+			return map[string]any{"alg": AlgES256, "sig": sign(t, authenticator.key, signed)}
+		})
+		credential, err := rp.VerifyRegistration(challenge, clientDataJSON, attestation)
+		if err != nil {
+			t.Fatalf("VerifyRegistration: %v", err)
+		}
+		if credential.Format != "packed" {
+			t.Errorf("format = %q, want packed", credential.Format)
+		}
+	})
+
+	t.Run("full attestation", func(t *testing.T) {
+		authenticator := newSoftAuthenticator(t, testRPID)
+		attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert := attestationCertificate(t, attestationKey, authenticator.aaguid)
+		challenge := newChallenge(t)
+
+		clientDataJSON, attestation := authenticator.register(challenge, testOrigin, "packed", func(signed []byte) map[string]any {
+			return map[string]any{"alg": AlgES256, "sig": sign(t, attestationKey, signed), "x5c": [][]byte{cert}}
+		})
+		if _, err := rp.VerifyRegistration(challenge, clientDataJSON, attestation); err != nil {
+			t.Fatalf("VerifyRegistration: %v", err)
+		}
+	})
+
+	t.Run("signature by another key", func(t *testing.T) {
+		authenticator := newSoftAuthenticator(t, testRPID)
+		other := newSoftAuthenticator(t, testRPID)
+		challenge := newChallenge(t)
+
+		clientDataJSON, attestation := authenticator.register(challenge, testOrigin, "packed", func(signed []byte) map[string]any {
+			return map[string]any{"alg": AlgES256, "sig": sign(t, other.key, signed)}
+		})
+		if _, err := rp.VerifyRegistration(challenge, clientDataJSON, attestation); !errors.Is(err, ErrInvalidAttestation) {
+			t.Fatalf("err = %v, want %v", err, ErrInvalidAttestation)
+		}
+	})
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	rp := testRP()
+	authenticator := newSoftAuthenticator(t, testRPID)
+	challenge := newChallenge(t)
+
+	clientDataJSON, attestation := authenticator.register(challenge, testOrigin, "none", nil)
+	credential, err := rp.VerifyRegistration(challenge, clientDataJSON, attestation)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		challenge := newChallenge(t)
+		clientDataJSON, authData, signature := authenticator.assert(challenge, testOrigin)
+
+		result, err := rp.VerifyAssertion(challenge, credential, clientDataJSON, authData, signature)
+		if err != nil {
+			t.Fatalf("assertion %d: %v", i, err)
+		}
+		if result.SignCount != uint32(i) || !result.UserVerified {
+			t.Fatalf("assertion %d: unexpected result %+v", i, result)
+		}
+		credential.SignCount = result.SignCount
+	}
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	rp := testRP()
+
+	tests := []struct {
+		name    string
+		rpID    string
+		origin  string
+		stored  uint32 // счетчик, сохраненный на сервере
+		counter uint32 // счетчик аутентификатора до подписи
+		wantErr error
+	}{
+		{name: "wrong origin", rpID: testRPID, origin: "https://evil.example", wantErr: ErrOriginMismatch},
+		{name: "wrong rp id", rpID: "evil.example", origin: testOrigin, wantErr: ErrRPIDMismatch},
+		{name: "sign count rollback", rpID: testRPID, origin: testOrigin, stored: 5, counter: 3, wantErr: ErrSignCountRollback},
+		{name: "sign count replay", rpID: testRPID, origin: testOrigin, stored: 5, counter: 4, wantErr: ErrSignCountRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, tt.rpID)
+			authenticator.signCount = tt.counter
+			credential := Credential{ID: authenticator.credentialID, PublicKey: authenticator.coseKey(), SignCount: tt.stored}
+			challenge := newChallenge(t)
+
+			clientDataJSON, authData, signature := authenticator.assert(challenge, tt.origin)
+			if _, err := rp.VerifyAssertion(challenge, credential, clientDataJSON, authData, signature); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionZeroCounter(t *testing.T) {
+	rp := testRP()
+	authenticator := newSoftAuthenticator(t, testRPID)
+	authenticator.noCounter = true
+	credential := Credential{ID: authenticator.credentialID, PublicKey: authenticator.coseKey()}
+
+	for i := 0; i < 2; i++ {
+		challenge := newChallenge(t)
+		clientDataJSON, authData, signature := authenticator.assert(challenge, testOrigin)
+
+		result, err := rp.VerifyAssertion(challenge, credential, clientDataJSON, authData, signature)
+		if err != nil {
+			t.Fatalf("VerifyAssertion: %v", err)
+		}
+		if result.SignCount != 0 {
+			t.Fatalf("sign count = %d, want 0", result.SignCount)
+		}
+	}
+}
@@ -5,201 +5,560 @@ import (
 	"errors"
 	"fmt"
 	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/encrypt"
 	"github.com/AronditFire/User-Service/internal/lib/jwt"
+	"github.com/AronditFire/User-Service/internal/lib/mailer"
+	"github.com/AronditFire/User-Service/internal/lib/passhash"
+	"github.com/AronditFire/User-Service/internal/lib/passpolicy"
+	"github.com/AronditFire/User-Service/internal/lib/sms"
+	"github.com/AronditFire/User-Service/internal/lib/tokenhash"
+	"github.com/AronditFire/User-Service/internal/lib/webauthn"
 	"github.com/AronditFire/User-Service/internal/storage"
+	val "github.com/AronditFire/User-Service/internal/validator"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"log/slog"
 	"time"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
-	// ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrTokenReused        = errors.New("refresh token reuse detected")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrUserBlocked        = errors.New("user is blocked")
+	ErrEmailNotVerified   = errors.New("email is not verified")
 )
 
 type Auth struct {
 	log          *slog.Logger
 	userSaver    UserSaver
 	userProvider UserProvider
-	roleSetter   RoleSetter
 	roleProvider RoleProvider
 	tokenRepo    TokenRepo
+	revoker      AccessRevoker
+	mfaRepo      MFARepo
+	encryptor    *encrypt.Encryptor // шифрует TOTP секреты
+	mfa          MFAOptions
+	passkeys     PasskeyRepo
+	rp           *webauthn.RelyingParty
+	verifier     EmailVerifier
+	passwords    PasswordRepo
+	mailer       mailer.Mailer
+	sms          sms.SMSSender
+	reset        PasswordResetOptions
+	policy       *passpolicy.Policy
+	hasher       passhash.PasswordHasher
+	throttle     LoginThrottleRepo
+	lockout      LockoutOptions
+	oauthRepo    OAuthRepo
+	oauth        OAuthOptions
 	accessTTL    time.Duration
 	refreshTTL   time.Duration
-	secret       string
+	keys         *jwt.KeySet
+	tokenSecret  string // ключ HMAC для refresh токенов
 	defaultRole  string
+
+	requireVerifiedEmail bool   // запрещает вход до подтверждения email
+	dummyHash            string // проверяется вместо хеша несуществующего пользователя
 }
 
 type UserSaver interface {
-	SaveUser(ctx context.Context, username string, email string, FIO string, phoneNumber string, passHash string) (int64, error)
+	// SaveUser saves the user and assigns the role atomically
+	SaveUser(ctx context.Context, username string, email string, FIO string, phoneNumber string, passHash string, role string) (int64, error)
 }
 
-type RoleSetter interface {
-	SetRole(ctx context.Context, userID int64, role string) error
+// TokenRepo works with refresh token hashes only
+type TokenRepo interface {
+	SaveToken(ctx context.Context, tokenHash string, userID int64, familyID string, info models.SessionInfo, access models.AccessToken, grant models.OAuthGrant, expiresAt time.Time) error
+	GetToken(ctx context.Context, tokenHash string) (models.RefreshTokenClaims, error)
+	RotateToken(ctx context.Context, oldTokenHash string, newTokenHash string, info models.SessionInfo, access models.AccessToken, expiresAt time.Time) error
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	ListSessions(ctx context.Context, userID int64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID int64, exceptSessionID string) error
 }
 
-type TokenRepo interface {
-	SaveToken(ctx context.Context, refreshToken string, userID int64, expiresAt time.Time) error
-	GetToken(ctx context.Context, refreshToken string) (models.RefreshTokenClaims, error)
-	DeleteToken(ctx context.Context, refreshToken string) error
+// AccessRevoker puts issued access tokens on the denylist
+type AccessRevoker interface {
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeUser(ctx context.Context, userID int64) error
+	RevokeClient(ctx context.Context, clientID string) error
+	IsRevoked(ctx context.Context, jti string) bool
 }
 
 type UserProvider interface {
 	User(ctx context.Context, username string) (models.User, error)
+	UserByID(ctx context.Context, userID int64) (models.User, error)
+	UserByEmail(ctx context.Context, email string) (models.User, error)
+	UserByPhone(ctx context.Context, phoneNumber string) (models.User, error)
+}
+
+// EmailVerifier sends the verification link to a newly registered user
+type EmailVerifier interface {
+	SendVerificationEmailToUser(ctx context.Context, userID int64) error
 }
+
 type RoleProvider interface {
 	Role(ctx context.Context, userID int64) (string, error)
 }
 
-func New(
-	log *slog.Logger,
-	userSaver UserSaver,
-	provider UserProvider,
-	roleSetter RoleSetter,
-	roleProvider RoleProvider,
-	tokenRepo TokenRepo,
-	accessTTL time.Duration,
-	refreshTTL time.Duration,
-	secret string,
-	defaultRole string,
-) *Auth {
+// Deps хранилища и сервисы, с которыми работает Auth; обычно почти все репозитории - одно хранилище
+type Deps struct {
+	UserSaver    UserSaver
+	UserProvider UserProvider
+	RoleProvider RoleProvider
+	TokenRepo    TokenRepo
+	Revoker      AccessRevoker
+	MFARepo      MFARepo
+	Encryptor    *encrypt.Encryptor // шифрует TOTP секреты
+	Passkeys     PasskeyRepo
+	RelyingParty *webauthn.RelyingParty
+	Verifier     EmailVerifier
+	Passwords    PasswordRepo
+	Mailer       mailer.Mailer
+	SMS          sms.SMSSender
+	Policy       *passpolicy.Policy
+	Hasher       passhash.PasswordHasher
+	Throttle     LoginThrottleRepo
+	OAuthRepo    OAuthRepo
+	Keys         *jwt.KeySet
+}
+
+// Options настройки Auth из конфига
+type Options struct {
+	AccessTTL            time.Duration
+	RefreshTTL           time.Duration
+	TokenSecret          string // ключ HMAC для refresh токенов
+	DefaultRole          string
+	RequireVerifiedEmail bool // запрещает вход до подтверждения email
+	MFA                  MFAOptions
+	PasswordReset        PasswordResetOptions
+	Lockout              LockoutOptions
+	OAuth                OAuthOptions
+}
+
+func New(log *slog.Logger, deps Deps, opts Options) *Auth {
+	// хеш текущим алгоритмом, чтобы вход с неизвестным логином занимал столько же времени
+	dummyHash, err := deps.Hasher.Hash(uuid.NewString())
+	if err != nil {
+		log.Error("failed to generate dummy password hash", slog.String("error", err.Error()))
+	}
+
 	return &Auth{
 		log:          log,
-		userSaver:    userSaver,
-		userProvider: provider,
-		roleSetter:   roleSetter,
-		roleProvider: roleProvider,
-		tokenRepo:    tokenRepo,
-		accessTTL:    accessTTL,
-		refreshTTL:   refreshTTL,
-		secret:       secret,
-		defaultRole:  defaultRole,
+		userSaver:    deps.UserSaver,
+		userProvider: deps.UserProvider,
+		roleProvider: deps.RoleProvider,
+		tokenRepo:    deps.TokenRepo,
+		revoker:      deps.Revoker,
+		mfaRepo:      deps.MFARepo,
+		encryptor:    deps.Encryptor,
+		mfa:          opts.MFA,
+		passkeys:     deps.Passkeys,
+		rp:           deps.RelyingParty,
+		verifier:     deps.Verifier,
+		passwords:    deps.Passwords,
+		mailer:       deps.Mailer,
+		sms:          deps.SMS,
+		reset:        opts.PasswordReset,
+		policy:       deps.Policy,
+		hasher:       deps.Hasher,
+		throttle:     deps.Throttle,
+		lockout:      opts.Lockout,
+		oauthRepo:    deps.OAuthRepo,
+		oauth:        opts.OAuth,
+		accessTTL:    opts.AccessTTL,
+		refreshTTL:   opts.RefreshTTL,
+		keys:         deps.Keys,
+		tokenSecret:  opts.TokenSecret,
+		defaultRole:  opts.DefaultRole,
+
+		requireVerifiedEmail: opts.RequireVerifiedEmail,
+		dummyHash:            dummyHash,
 	}
 }
 
-// RegisterUser creates a new user and assigns the default role.
-func (a *Auth) RegisterUser(ctx context.Context, username, email, FIO, phoneNumber, password string) (int64, error) {
+// RegisterUser creates a new user with the default role in one transaction.
+// A conflict with an existing user is not reported to the caller, see notifyRegistrationConflict.
+func (a *Auth) RegisterUser(ctx context.Context, username, email, FIO, phoneNumber, password string) error {
 	const op = "auth.RegisterUser"
 
 	log := a.log.With(slog.String("op", op), slog.String("username", username))
 	log.Info("registering user")
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := a.checkPasswordPolicy(password, models.User{Username: username, Email: email, FIO: FIO}); err != nil {
+		log.Info("password rejected by policy")
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.hashPassword(password)
 	if err != nil {
 		a.log.Error("failed to generate password hash", slog.String("error", err.Error()))
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	userID, err := a.userSaver.SaveUser(ctx, username, email, FIO, phoneNumber, string(passHash))
+	// email и телефон храним нормализованными, чтобы по ним можно было войти
+	email, phoneNumber = val.NormalizeEmail(email), val.NormalizePhone(phoneNumber)
+	userID, err := a.userSaver.SaveUser(ctx, username, email, FIO, phoneNumber, passHash, a.defaultRole)
 	if err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			// ответ как при успехе, иначе регистрация проверяет, есть ли аккаунт с этим username, email или телефоном.
+			// О конфликте сообщаем письмом или SMS, в фоне, как и письмо подтверждения
+			log.Warn("registration conflicts with an existing user", slog.String("error", err.Error()))
+			go a.notifyRegistrationConflict(context.WithoutCancel(ctx), log, err, username, email, phoneNumber)
+			return nil
+		}
 		a.log.Error("failed to save user", slog.String("error", err.Error()))
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := a.roleSetter.SetRole(ctx, userID, a.defaultRole); err != nil {
-		a.log.Error("failed to set default role", slog.String("error", err.Error()))
-		return 0, fmt.Errorf("%s: %w", op, err)
-	}
+	// письмо не критично для регистрации, его можно запросить повторно.
+	// Отправка в фоне, иначе по времени ответа успешная регистрация отличается от занятого email
+	go func() {
+		if err := a.verifier.SendVerificationEmailToUser(context.WithoutCancel(ctx), userID); err != nil {
+			log.Warn("failed to send verification email", slog.String("error", err.Error()))
+		}
+	}()
 
 	log.Info("successfully registered user")
-	return userID, nil
+	return nil
+}
+
+// notifyRegistrationConflict tells the owner of the email or phone number that someone tried to register with it;
+// если занят username, письмо получает сам регистрирующийся на указанный email
+func (a *Auth) notifyRegistrationConflict(ctx context.Context, log *slog.Logger, conflict error, username, email, phoneNumber string) {
+	switch {
+	case errors.Is(conflict, storage.ErrEmailTaken):
+		user, err := a.userProvider.UserByEmail(ctx, email)
+		if err != nil {
+			log.Warn("failed to get email owner", slog.String("error", err.Error()))
+			return
+		}
+		err = a.mailer.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Попытка регистрации с вашим email",
+			Body: fmt.Sprintf("Здравствуйте, %s!\n\nКто-то пытался зарегистрировать новый аккаунт с этим адресом. Аккаунт не создан.\nЕсли это были вы, войдите в существующий аккаунт или восстановите пароль. Если нет, просто проигнорируйте это письмо.\n",
+				user.Username),
+		})
+		if err != nil {
+			log.Warn("failed to send registration conflict email", slog.String("error", err.Error()))
+		}
+
+	case errors.Is(conflict, storage.ErrPhoneTaken):
+		user, err := a.userProvider.UserByPhone(ctx, phoneNumber)
+		if err != nil {
+			log.Warn("failed to get phone number owner", slog.String("error", err.Error()))
+			return
+		}
+		err = a.sms.Send(ctx, user.PhoneNumber, "Кто-то пытался зарегистрировать аккаунт с вашим номером. Если это были вы, войдите в существующий аккаунт.")
+		if err != nil {
+			log.Warn("failed to send registration conflict sms", slog.String("error", err.Error()))
+		}
+
+	default:
+		err := a.mailer.Send(ctx, mailer.Message{
+			To:      email,
+			Subject: "Регистрация не завершена",
+			Body:    fmt.Sprintf("Здравствуйте!\n\nИмя пользователя %s уже занято, аккаунт не создан. Зарегистрируйтесь заново с другим именем.\n", username),
+		})
+		if err != nil {
+			log.Warn("failed to send registration conflict email", slog.String("error", err.Error()))
+		}
+	}
 }
 
-// Login generate tokens if username and password correct
-func (a *Auth) Login(ctx context.Context, username, password string) (string, string, error) {
+// Login generate tokens if login (username, email or phone number) and password correct
+func (a *Auth) Login(ctx context.Context, login, password string, info models.SessionInfo) (string, string, error) {
 	const op = "auth.Login"
 
-	log := a.log.With(slog.String("op", op), slog.String("username", username))
+	log := a.log.With(slog.String("op", op), slog.String("login", login))
 	log.Info("trying to login user")
 
-	user, err := a.userProvider.User(ctx, username)
-	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			a.log.Warn("user not found", slog.String("error", err.Error()))
-			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	// попытки с одного адреса ограничиваются независимо от логина
+	ipKey := ipLockoutKey(info.IP)
+	var ipAttempt models.LoginFailures
+	if ipKey != "" {
+		var err error
+		if ipAttempt, err = a.acquireLoginAttempt(ctx, ipKey, a.lockout.IPFreeAttempts); err != nil {
+			log.Warn("login throttled by ip", slog.String("ip", info.IP), slog.String("error", err.Error()))
+			return "", "", fmt.Errorf("%s: %w", op, err)
 		}
+	}
+
+	user, err := a.userByLogin(ctx, login)
+	found := err == nil
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
 		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		a.refundLoginAttempt(ctx, log, ipKey)
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		a.log.Error("invalid password", slog.String("error", err.Error()))
+	accountKey := loginLockoutKey(login)
+	if found {
+		accountKey = userLockoutKey(user.ID)
+	}
+	accountAttempt, err := a.acquireLoginAttempt(ctx, accountKey, a.lockout.FreeAttempts)
+	if err != nil {
+		log.Warn("login throttled", slog.String("error", err.Error()))
+		a.refundLoginAttempt(ctx, log, ipKey)
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// для неизвестного логина пароль проверяется по dummy хешу, чтобы время ответа не выдавало,
+	// существует ли пользователь
+	passHash := a.dummyHash
+	if found {
+		passHash = string(user.PassHash)
+	}
+	ok, err := a.hasher.Verify(password, passHash)
+	if !found {
+		log.Warn("user not found")
+		a.lockIfExceeded(ctx, log, accountKey, accountAttempt, a.lockout.AccountLockThreshold)
+		a.lockIfExceeded(ctx, log, ipKey, ipAttempt, a.lockout.IPLockThreshold)
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if err != nil {
+		a.log.Error("failed to verify password", slog.String("error", err.Error()))
+		a.refundLoginAttempt(ctx, log, accountKey)
+		a.refundLoginAttempt(ctx, log, ipKey)
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		a.log.Warn("invalid password", slog.Int64("userID", user.ID))
+		a.lockIfExceeded(ctx, log, accountKey, accountAttempt, a.lockout.AccountLockThreshold)
+		a.lockIfExceeded(ctx, log, ipKey, ipAttempt, a.lockout.IPLockThreshold)
 		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
+	if err := a.throttle.ClearLoginFailures(ctx, accountKey, a.lockout.ResetAfter); err != nil {
+		log.Warn("failed to clear login failures", slog.String("error", err.Error()))
+	}
+	a.refundLoginAttempt(ctx, log, ipKey)
+	a.rehashPassword(ctx, log, user, password)
+
+	if err := a.checkLoginAllowed(log, user); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
 
-	role, err := a.roleProvider.Role(ctx, user.ID) // GET USER ROLE
+	// при включённой 2FA токены выдаст VerifyMFA
+	if err := a.mfaChallenge(ctx, user.ID); err != nil {
+		if errors.Is(err, ErrMFARequired) {
+			log.Info("second factor required", slog.Int64("userID", user.ID))
+			return "", "", fmt.Errorf("%s: %w", op, err)
+		}
+		a.log.Error("failed to check mfa", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, refreshToken, err := a.startSession(ctx, user.ID, info)
 	if err != nil {
-		a.log.Error("failed to get role", err.Error())
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
+	log.Info("successfully logged in", slog.Int64("userID", user.ID))
+
+	return accessToken, refreshToken, nil
+}
+
+// checkLoginAllowed is checked by every login method before tokens are issued
+func (a *Auth) checkLoginAllowed(log *slog.Logger, user models.User) error {
+	if user.BlockedAt != nil {
+		log.Warn("blocked user tried to login")
+		return ErrUserBlocked
+	}
+	if a.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		log.Info("user with unverified email tried to login")
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// startSession starts a new token family (session) and issues its first token pair
+func (a *Auth) startSession(ctx context.Context, userID int64, info models.SessionInfo) (string, string, error) {
+	// each login starts a new token family (session), every refresh stays inside it
+	return a.startFamily(ctx, userID, uuid.NewString(), info, models.OAuthGrant{})
+}
+
+// startFamily issues the first token pair of the family, grant is empty for first-party sessions
+func (a *Auth) startFamily(ctx context.Context, userID int64, familyID string, info models.SessionInfo, grant models.OAuthGrant) (string, string, error) {
+	role, err := a.roleProvider.Role(ctx, userID) // GET USER ROLE
+	if err != nil {
+		a.log.Error("failed to get role", slog.String("error", err.Error()))
+		return "", "", err
+	}
 
-	accessToken, err := jwt.GenerateToken(user.ID, role, a.secret, a.accessTTL)
+	accessToken, access, err := a.issueAccessToken(userID, role, familyID, grant)
 	if err != nil {
 		a.log.Error("failed to generate token", slog.String("error", err.Error()))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return "", "", err
 	}
 
 	refreshToken := uuid.NewString()
 	refreshExpiresAt := time.Now().Add(a.refreshTTL)
-	if err := a.tokenRepo.SaveToken(ctx, refreshToken, user.ID, refreshExpiresAt); err != nil {
+	if err := a.tokenRepo.SaveToken(ctx, a.hashToken(refreshToken), userID, familyID, info, access, grant, refreshExpiresAt); err != nil {
 		a.log.Error("failed to save refresh token", slog.String("error", err.Error()))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return "", "", err
 	}
-	log.Info("successfully logged in", slog.String("username", username))
 
 	return accessToken, refreshToken, nil
 }
 
-func (a *Auth) Refresh(ctx context.Context, refreshToken string) (string, string, error) { // TODO: изучить логику подробнее
+// Refresh rotates refresh token inside its family and issues a new token pair.
+// Presenting an already rotated token revokes the whole family.
+func (a *Auth) Refresh(ctx context.Context, refreshToken string, info models.SessionInfo) (string, string, error) {
 	const op = "auth.Refresh"
 	log := a.log.With(slog.String("op", op))
 	log.Info("trying to refresh tokens")
 
-	refreshData, err := a.tokenRepo.GetToken(ctx, refreshToken) // достаём данные из таблицы refreshTokens
+	refreshData, err := a.refreshTokenData(ctx, refreshToken)
 	if err != nil {
-		a.log.Error("failed to get old refresh token", slog.String("error", err.Error()))
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := a.tokenRepo.DeleteToken(ctx, refreshToken); err != nil { // Удаляем старый refresh Token
-		a.log.Error("failed to delete old refresh token", slog.String("error", err.Error()))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+	log = log.With(slog.Int64("userID", refreshData.UserID), slog.String("familyID", refreshData.FamilyID))
+
+	// токены OAuth клиентов обновляются только через /oauth2/token
+	if refreshData.Grant.ClientID != "" {
+		log.Warn("oauth client refresh token presented to first-party refresh")
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
 	}
 
-	role, err := a.roleProvider.Role(ctx, refreshData.UserID) // TODO: убрать ошибку
+	newAccessToken, newRefreshToken, err := a.rotateRefreshToken(ctx, log, refreshToken, refreshData, refreshData.Grant, info)
 	if err != nil {
-		a.log.Error("failed to get role", err.Error())
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	newAccessToken, err := jwt.GenerateToken(refreshData.UserID, role, a.secret, a.accessTTL)
+	log.Info("successfully refreshed tokens")
+	return newAccessToken, newRefreshToken, nil
+}
+
+func (a *Auth) refreshTokenData(ctx context.Context, refreshToken string) (models.RefreshTokenClaims, error) {
+	refreshData, err := a.tokenRepo.GetToken(ctx, a.hashToken(refreshToken)) // достаём данные из таблицы refreshTokens
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			a.log.Warn("refresh token not found", slog.String("error", err.Error()))
+			return models.RefreshTokenClaims{}, ErrInvalidToken
+		}
+		a.log.Error("failed to get old refresh token", slog.String("error", err.Error()))
+		return models.RefreshTokenClaims{}, err
+	}
+	return refreshData, nil
+}
+
+// rotateRefreshToken checks the token and rotates it inside its family keeping the grant of the family
+func (a *Auth) rotateRefreshToken(ctx context.Context, log *slog.Logger, refreshToken string, refreshData models.RefreshTokenClaims, accessGrant models.OAuthGrant, info models.SessionInfo) (string, string, error) {
+	if refreshData.RevokedAt != nil {
+		log.Warn("refresh token from revoked family presented")
+		return "", "", ErrInvalidToken
+	}
+	if refreshData.UsedAt != nil {
+		return "", "", a.revokeReusedFamily(ctx, log, refreshData.FamilyID)
+	}
+	if time.Now().After(refreshData.ExpiresAt) {
+		log.Info("refresh token expired")
+		return "", "", ErrInvalidToken
+	}
+
+	role, err := a.roleProvider.Role(ctx, refreshData.UserID)
+	if err != nil {
+		a.log.Error("failed to get role", slog.String("error", err.Error()))
+		return "", "", err
+	}
+
+	newAccessToken, access, err := a.issueAccessToken(refreshData.UserID, role, refreshData.FamilyID, accessGrant)
 	if err != nil {
 		a.log.Error("failed to generate access token", slog.String("error", err.Error()))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return "", "", err
 	}
 
 	expiresAt := time.Now().Add(a.refreshTTL)
 	newRefreshToken := uuid.NewString()
-	if err := a.tokenRepo.SaveToken(ctx, newRefreshToken, refreshData.UserID, expiresAt); err != nil {
-		a.log.Error("failed to save new refresh token", slog.String("error", err.Error()))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+	if err := a.tokenRepo.RotateToken(ctx, a.hashToken(refreshToken), a.hashToken(newRefreshToken), info, access, expiresAt); err != nil {
+		if errors.Is(err, storage.ErrTokenReused) { // кто-то успел использовать токен параллельно
+			return "", "", a.revokeReusedFamily(ctx, log, refreshData.FamilyID)
+		}
+		a.log.Error("failed to rotate refresh token", slog.String("error", err.Error()))
+		return "", "", err
 	}
 
-	log.Info("successfully refreshed tokens", slog.Int64("userID", refreshData.UserID))
 	return newAccessToken, newRefreshToken, nil
 }
 
+// revokeReusedFamily revokes the family of a replayed refresh token and reports the security event
+func (a *Auth) revokeReusedFamily(ctx context.Context, log *slog.Logger, familyID string) error {
+	log.Warn("security event: refresh token reuse detected, revoking token family",
+		slog.String("event", "refresh_token_reuse"),
+	)
+
+	if err := a.tokenRepo.RevokeTokenFamily(ctx, familyID); err != nil {
+		a.log.Error("failed to revoke token family", slog.String("error", err.Error()))
+		return err
+	}
+	if err := a.revoker.RevokeSession(ctx, familyID); err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return err
+	}
+
+	return ErrTokenReused
+}
+
+// Logout revokes the token family (session) the refresh token belongs to
 func (a *Auth) Logout(ctx context.Context, refreshToken string) error {
 	const op = "auth.Logout"
-	log := a.log.With(slog.String("op", op), slog.String("refreshToken", refreshToken))
-	log.Info("start to delete refresh token")
+	log := a.log.With(slog.String("op", op))
+	log.Info("start to revoke refresh token family")
 
-	if err := a.tokenRepo.DeleteToken(ctx, refreshToken); err != nil {
-		a.log.Error("failed to delete refresh token", slog.String("error", err.Error()))
+	refreshData, err := a.tokenRepo.GetToken(ctx, a.hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			a.log.Warn("refresh token not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		a.log.Error("failed to get refresh token", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.tokenRepo.RevokeTokenFamily(ctx, refreshData.FamilyID); err != nil {
+		a.log.Error("failed to revoke refresh token family", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.revoker.RevokeSession(ctx, refreshData.FamilyID); err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	log.Info("token successfully deleted")
+	log.Info("token family successfully revoked", slog.String("familyID", refreshData.FamilyID))
 
 	return nil
 }
+
+// userByLogin resolves login against username, email or phone number column
+func (a *Auth) userByLogin(ctx context.Context, login string) (models.User, error) {
+	kind, value, err := val.ClassifyLogin(login)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	switch kind {
+	case val.LoginEmail:
+		return a.userProvider.UserByEmail(ctx, value)
+	case val.LoginPhone:
+		return a.userProvider.UserByPhone(ctx, value)
+	}
+	return a.userProvider.User(ctx, value)
+}
+
+// issueAccessToken generates access token for the session, returned AccessToken is stored for revocation.
+// Tokens of OAuth clients carry client_id and the granted scopes.
+func (a *Auth) issueAccessToken(userID int64, role, sessionID string, grant models.OAuthGrant) (string, models.AccessToken, error) {
+	access := models.AccessToken{ID: uuid.NewString()}
+
+	token, err := a.keys.GenerateToken(userID, role, sessionID, access.ID, grant.ClientID, grant.Scopes, a.accessTTL)
+	if err != nil {
+		return "", models.AccessToken{}, err
+	}
+	access.ExpiresAt = time.Now().Add(a.accessTTL)
+
+	return token, access, nil
+}
+
+func (a *Auth) hashToken(refreshToken string) string {
+	return tokenhash.Hash(refreshToken, a.tokenSecret)
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/jwt"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"log/slog"
+)
+
+// Introspect checks access token the same way the gRPC interceptor does
+// and that its owner (user or OAuth client) still exists and is not blocked.
+// Invalid tokens are reported as inactive, error only if the check failed.
+func (a *Auth) Introspect(ctx context.Context, accessToken string) (models.TokenIntrospection, error) {
+	const op = "auth.Introspect"
+	log := a.log.With(slog.String("op", op))
+
+	claims, err := a.keys.VerifyToken(accessToken)
+	if err != nil {
+		log.Debug("token is not valid", slog.String("error", err.Error()))
+		return models.TokenIntrospection{}, nil
+	}
+	if claims.ID == "" || a.revoker.IsRevoked(ctx, claims.ID) {
+		log.Debug("token is revoked", slog.String("jti", claims.ID))
+		return models.TokenIntrospection{}, nil
+	}
+
+	if claims.IsClient() {
+		return a.introspectClientToken(ctx, log, claims)
+	}
+
+	user, err := a.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Debug("token owner not found", slog.Int64("userID", claims.UserID))
+			return models.TokenIntrospection{}, nil
+		}
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return models.TokenIntrospection{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.BlockedAt != nil {
+		log.Debug("token owner is blocked", slog.Int64("userID", claims.UserID))
+		return models.TokenIntrospection{}, nil
+	}
+
+	result := models.TokenIntrospection{
+		Active:    true,
+		UserID:    claims.UserID,
+		Subject:   claims.Subject,
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+		TokenID:   claims.ID,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return result, nil
+}
+
+// introspectClientToken: токен client_credentials активен, пока клиент не удалён
+func (a *Auth) introspectClientToken(ctx context.Context, log *slog.Logger, claims *jwt.Claims) (models.TokenIntrospection, error) {
+	const op = "auth.Introspect"
+
+	if _, err := a.oauthRepo.OAuthClient(ctx, claims.ClientID); err != nil {
+		if errors.Is(err, storage.ErrOAuthClientNotFound) {
+			log.Debug("token client not found", slog.String("clientID", claims.ClientID))
+			return models.TokenIntrospection{}, nil
+		}
+		a.log.Error("failed to get oauth client", slog.String("error", err.Error()))
+		return models.TokenIntrospection{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := models.TokenIntrospection{
+		Active:    true,
+		Subject:   claims.Subject,
+		TokenID:   claims.ID,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return result, nil
+}
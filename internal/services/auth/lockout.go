@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+	ErrAccountLocked   = errors.New("login is temporarily locked")
+	ErrUserNotFound    = errors.New("user not found")
+)
+
+// LoginThrottledError говорит клиенту, когда можно повторить вход
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // достигнут порог блокировки, а не просто задержка между попытками
+}
+
+func (e *LoginThrottledError) Error() string {
+	return e.Unwrap().Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	if e.Locked {
+		return ErrAccountLocked
+	}
+	return ErrTooManyAttempts
+}
+
+// LockoutOptions: после FreeAttempts неудач каждая следующая попытка ждет BaseDelay, 2*BaseDelay ... до MaxDelay,
+// после LockThreshold неудач вход блокируется на LockDuration. 0 в порогах отключает блокировку.
+type LockoutOptions struct {
+	FreeAttempts         int
+	IPFreeAttempts       int
+	BaseDelay            time.Duration
+	MaxDelay             time.Duration
+	AccountLockThreshold int
+	IPLockThreshold      int
+	LockDuration         time.Duration
+	ResetAfter           time.Duration // счетчик начинается заново, если столько не было неудач
+}
+
+type LoginThrottleRepo interface {
+	AcquireLoginAttempt(ctx context.Context, key string, backoff models.LoginBackoff) (models.LoginFailures, bool, error)
+	RefundLoginAttempt(ctx context.Context, key string) error
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, key string, staleAfter time.Duration) error
+}
+
+// UnlockAccount resets failed login attempts of the user and lifts the temporary lock
+func (a *Auth) UnlockAccount(ctx context.Context, userID int64) error {
+	const op = "auth.UnlockAccount"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if _, err := a.userProvider.UserByID(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.throttle.ClearLoginFailures(ctx, userLockoutKey(userID), a.lockout.ResetAfter); err != nil {
+		a.log.Error("failed to clear login failures", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: account unlocked", slog.String("event", "account_unlocked"))
+
+	return nil
+}
+
+// acquireLoginAttempt counts the attempt as a failure before the password is checked, so parallel attempts
+// can't pass the same check; returns *LoginThrottledError while the key is locked or has to wait
+func (a *Auth) acquireLoginAttempt(ctx context.Context, key string, freeAttempts int) (models.LoginFailures, error) {
+	f, ok, err := a.throttle.AcquireLoginAttempt(ctx, key, models.LoginBackoff{
+		FreeAttempts: freeAttempts,
+		BaseDelay:    a.lockout.BaseDelay,
+		MaxDelay:     a.lockout.MaxDelay,
+		ResetAfter:   a.lockout.ResetAfter,
+	})
+	if err != nil {
+		a.log.Error("failed to acquire login attempt", slog.String("error", err.Error()))
+		return models.LoginFailures{}, err
+	}
+	if ok {
+		return f, nil
+	}
+
+	now := time.Now()
+	if f.LockedUntil != nil && f.LockedUntil.After(now) {
+		return f, &LoginThrottledError{RetryAfter: f.LockedUntil.Sub(now), Locked: true}
+	}
+	// часы БД и сервиса могут расходиться, поэтому не меньше секунды
+	retryAfter := f.LastFailureAt.Add(a.loginDelay(f.Failures, freeAttempts)).Sub(now)
+	return f, &LoginThrottledError{RetryAfter: max(retryAfter, time.Second)}
+}
+
+// refundLoginAttempt returns the attempt that was not a failure (success or server error)
+func (a *Auth) refundLoginAttempt(ctx context.Context, log *slog.Logger, key string) {
+	if key == "" {
+		return
+	}
+	if err := a.throttle.RefundLoginAttempt(ctx, key); err != nil {
+		log.Warn("failed to refund login attempt", slog.String("error", err.Error()))
+	}
+}
+
+// lockIfExceeded locks the key when its counted failures reached the threshold
+func (a *Auth) lockIfExceeded(ctx context.Context, log *slog.Logger, key string, f models.LoginFailures, threshold int) {
+	if key == "" || threshold <= 0 || f.Failures < threshold {
+		return
+	}
+
+	until := time.Now().Add(a.lockout.LockDuration)
+	if err := a.throttle.LockLogin(ctx, key, until); err != nil {
+		log.Error("failed to lock login", slog.String("error", err.Error()))
+		return
+	}
+	log.Warn("security event: login locked",
+		slog.String("event", "login_locked"),
+		slog.String("key", key),
+		slog.Int("failures", f.Failures),
+		slog.Time("lockedUntil", until),
+	)
+}
+
+// loginDelay is the exponential backoff after the given number of failures
+func (a *Auth) loginDelay(failures, freeAttempts int) time.Duration {
+	n := failures - freeAttempts
+	if n <= 0 {
+		return 0
+	}
+
+	delay := a.lockout.BaseDelay
+	for i := 1; i < n && delay < a.lockout.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, a.lockout.MaxDelay)
+}
+
+func userLockoutKey(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+// неизвестные логины тоже ограничиваются, иначе по ответу видно, что пользователя нет
+func loginLockoutKey(login string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(login))
+}
+
+// ip берется из SessionInfo, то есть x-forwarded-for учитывается только от доверенных прокси
+func ipLockoutKey(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
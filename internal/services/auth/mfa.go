@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/totp"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrMFARequired       = errors.New("second factor required")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFADisabled       = errors.New("two-factor authentication is disabled on this server")
+	ErrInvalidMFACode    = errors.New("invalid code")
+	ErrInvalidChallenge  = errors.New("invalid or expired mfa token")
+)
+
+// допуск по времени: предыдущее и следующее окно кода
+const totpSkew = 1
+
+// MFARequiredError is returned by Login instead of tokens when the user has 2FA enabled
+type MFARequiredError struct {
+	Token     string // mfa token for VerifyMFA
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string { return ErrMFARequired.Error() }
+
+func (e *MFARequiredError) Unwrap() error { return ErrMFARequired }
+
+type MFAOptions struct {
+	Enabled       bool   // выключено: новые пользователи не могут включить TOTP
+	Issuer        string // отображается в приложении-аутентификаторе
+	ChallengeTTL  time.Duration
+	MaxAttempts   int // попыток ввода кода на один mfa token
+	RecoveryCodes int // сколько recovery codes выдавать
+}
+
+type MFARepo interface {
+	SaveTOTP(ctx context.Context, userID int64, secret []byte, recoveryCodeHashes []string) error
+	TOTP(ctx context.Context, userID int64) (models.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID int64, step int64) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+	SaveMFAChallenge(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error
+	MFAChallengeAttempt(ctx context.Context, tokenHash string) (models.MFAChallenge, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	RecoveryCodes(ctx context.Context, userID int64) ([]models.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, codeID int64) error
+}
+
+// EnrollTOTP generates a new secret and recovery codes, 2FA stays disabled until ConfirmTOTP
+func (a *Auth) EnrollTOTP(ctx context.Context, userID int64) (models.TOTPEnrollment, error) {
+	const op = "auth.EnrollTOTP"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if !a.mfa.Enabled {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrMFADisabled)
+	}
+
+	user, err := a.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		a.log.Error("failed to generate totp secret", slog.String("error", err.Error()))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	encrypted, err := a.encryptor.Encrypt([]byte(secret))
+	if err != nil {
+		a.log.Error("failed to encrypt totp secret", slog.String("error", err.Error()))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	recoveryCodes, recoveryHashes, err := a.generateRecoveryCodes()
+	if err != nil {
+		a.log.Error("failed to generate recovery codes", slog.String("error", err.Error()))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfaRepo.SaveTOTP(ctx, userID, encrypted, recoveryHashes); err != nil {
+		if errors.Is(err, storage.ErrMFAAlreadyEnabled) {
+			return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+		}
+		a.log.Error("failed to save totp secret", slog.String("error", err.Error()))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("totp enrollment started")
+
+	return models.TOTPEnrollment{
+		Secret:        secret,
+		URI:           totp.URI(a.mfa.Issuer, user.Username, secret),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// ConfirmTOTP enables 2FA once the user proves the authenticator is set up
+func (a *Auth) ConfirmTOTP(ctx context.Context, userID int64, code string) error {
+	const op = "auth.ConfirmTOTP"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	stored, err := a.mfaRepo.TOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return fmt.Errorf("%s: %w", op, ErrMFANotEnabled)
+		}
+		a.log.Error("failed to get totp", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if stored.ConfirmedAt != nil {
+		return fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+
+	step, err := a.validateTOTP(stored, code)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfaRepo.ConfirmTOTP(ctx, userID, step); err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return fmt.Errorf("%s: %w", op, ErrMFANotEnabled)
+		}
+		a.log.Error("failed to confirm totp", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: two-factor authentication enabled", slog.String("event", "mfa_enabled"))
+
+	return nil
+}
+
+// DisableTOTP requires a valid code or a recovery code, so a stolen access token is not enough to turn 2FA off
+func (a *Auth) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	const op = "auth.DisableTOTP"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if err := a.checkSecondFactor(ctx, log, userID, code); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfaRepo.DeleteTOTP(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return fmt.Errorf("%s: %w", op, ErrMFANotEnabled)
+		}
+		a.log.Error("failed to delete totp", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: two-factor authentication disabled", slog.String("event", "mfa_disabled"))
+
+	return nil
+}
+
+// VerifyMFA completes the login started by Login and issues the token pair
+func (a *Auth) VerifyMFA(ctx context.Context, mfaToken, code string, info models.SessionInfo) (string, string, error) {
+	const op = "auth.VerifyMFA"
+	log := a.log.With(slog.String("op", op))
+
+	challengeHash := a.hashToken(mfaToken)
+	challenge, err := a.mfaRepo.MFAChallengeAttempt(ctx, challengeHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeMissing) {
+			log.Warn("mfa token not found or expired")
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidChallenge)
+		}
+		a.log.Error("failed to get mfa challenge", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", challenge.UserID))
+
+	if challenge.Attempts > a.mfa.MaxAttempts {
+		log.Warn("security event: too many mfa attempts", slog.String("event", "mfa_attempts_exceeded"))
+		if err := a.mfaRepo.DeleteMFAChallenge(ctx, challengeHash); err != nil {
+			a.log.Error("failed to delete mfa challenge", slog.String("error", err.Error()))
+		}
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidChallenge)
+	}
+
+	if err := a.checkSecondFactor(ctx, log, challenge.UserID, code); err != nil {
+		log.Warn("invalid second factor", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfaRepo.DeleteMFAChallenge(ctx, challengeHash); err != nil {
+		a.log.Error("failed to delete mfa challenge", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// пока вводили код, аккаунт могли заблокировать
+	user, err := a.userProvider.UserByID(ctx, challenge.UserID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.checkLoginAllowed(log, user); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, refreshToken, err := a.startSession(ctx, user.ID, info)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("successfully logged in with second factor")
+
+	return accessToken, refreshToken, nil
+}
+
+// mfaChallenge returns MFARequiredError if the user has confirmed 2FA, nil otherwise
+func (a *Auth) mfaChallenge(ctx context.Context, userID int64) error {
+	enabled, err := a.totpEnabled(ctx, userID)
+	if err != nil || !enabled {
+		return err
+	}
+
+	token := uuid.NewString()
+	expiresAt := time.Now().Add(a.mfa.ChallengeTTL)
+	if err := a.mfaRepo.SaveMFAChallenge(ctx, a.hashToken(token), userID, expiresAt); err != nil {
+		return err
+	}
+
+	return &MFARequiredError{Token: token, ExpiresAt: expiresAt}
+}
+
+func (a *Auth) totpEnabled(ctx context.Context, userID int64) (bool, error) {
+	stored, err := a.mfaRepo.TOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return stored.ConfirmedAt != nil, nil
+}
+
+// checkTOTP validates the code of the enabled authenticator and burns its time window
+func (a *Auth) checkTOTP(ctx context.Context, userID int64, code string) error {
+	stored, err := a.mfaRepo.TOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return ErrMFANotEnabled
+		}
+		return err
+	}
+	if stored.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	step, err := a.validateTOTP(stored, code)
+	if err != nil {
+		return err
+	}
+
+	if err := a.mfaRepo.UseTOTPStep(ctx, userID, step); err != nil {
+		if errors.Is(err, storage.ErrTOTPStepUsed) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (a *Auth) validateTOTP(stored models.TOTP, code string) (int64, error) {
+	if a.encryptor == nil {
+		return 0, ErrMFADisabled
+	}
+	secret, err := a.encryptor.Decrypt(stored.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now(), totpSkew)
+	if !ok || step <= stored.LastUsedStep {
+		return 0, ErrInvalidMFACode
+	}
+
+	return step, nil
+}
@@ -0,0 +1,731 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/google/uuid"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOAuthClientNotFound          = errors.New("oauth client not found")
+	ErrInvalidOAuthClient           = errors.New("invalid oauth client")
+	ErrAuthorizationRequestNotFound = errors.New("authorization request not found or expired")
+)
+
+// OAuthScopes scope'ы, которые могут получить OAuth клиенты, с описанием для страницы согласия.
+// Какие методы открывает каждый scope, решает gRPC interceptor.
+var OAuthScopes = map[string]string{
+	"profile":       "Просмотр профиля",
+	"sessions":      "Просмотр и завершение сессий",
+	"users:read":    "Просмотр списка пользователей",
+	ScopeIntrospect: "Проверка токенов (introspection)",
+}
+
+// ScopeIntrospect разрешает сервису проверять чужие токены; выдается только по client_credentials
+const ScopeIntrospect = "introspect"
+
+// Grant types, которые можно разрешить клиенту; refresh_token доступен вместе с authorization_code
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// Коды ошибок OAuth 2.0 (RFC 6749, 4.1.2.1 и 5.2)
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthAccessDenied            = "access_denied"
+)
+
+// OAuthError ошибка протокола, которую видит клиент. Если задан Redirect, браузер
+// отправляется на него, иначе ошибка показывается пользователю (неизвестный клиент или redirect_uri).
+type OAuthError struct {
+	Code        string
+	Description string
+	Redirect    string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// OAuthClientError объясняет, почему клиент не может быть зарегистрирован, errors.Is(err, ErrInvalidOAuthClient) == true
+type OAuthClientError struct {
+	Reason string
+}
+
+func (e *OAuthClientError) Error() string {
+	return ErrInvalidOAuthClient.Error() + ": " + e.Reason
+}
+
+func (e *OAuthClientError) Unwrap() error {
+	return ErrInvalidOAuthClient
+}
+
+type OAuthOptions struct {
+	ConsentURL string        // страница фронтенда, request_id добавляется параметром
+	RequestTTL time.Duration // сколько ждём входа и согласия пользователя
+	CodeTTL    time.Duration
+}
+
+type OAuthRepo interface {
+	SaveOAuthClient(ctx context.Context, client models.OAuthClient) error
+	OAuthClient(ctx context.Context, clientID string) (models.OAuthClient, error)
+	DeleteOAuthClient(ctx context.Context, clientID string) error
+	SaveAuthorizationRequest(ctx context.Context, idHash string, req models.AuthorizationRequest) error
+	AuthorizationRequest(ctx context.Context, idHash string) (models.AuthorizationRequest, error)
+	TakeAuthorizationRequest(ctx context.Context, idHash string) (models.AuthorizationRequest, error)
+	SaveAuthorizationCode(ctx context.Context, codeHash string, code models.AuthorizationCode) error
+	AuthorizationCode(ctx context.Context, codeHash string) (models.AuthorizationCode, error)
+	UseAuthorizationCode(ctx context.Context, codeHash string, checked models.AuthorizationCode, familyID string) (models.AuthorizationCode, error)
+	SaveClientAccessToken(ctx context.Context, clientID string, access models.AccessToken) error
+}
+
+// AuthorizeRequest параметры /authorize
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// OAuthTokens ответ token endpoint
+type OAuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+	Scopes       []string
+}
+
+// CreateOAuthClient registers a client. Without grant types it is a public authorization_code client
+// authenticated with PKCE only. A client_credentials client gets a secret, it is returned only here.
+func (a *Auth) CreateOAuthClient(ctx context.Context, name string, redirectURIs, scopes, grantTypes []string) (models.OAuthClient, string, error) {
+	const op = "auth.CreateOAuthClient"
+	log := a.log.With(slog.String("op", op))
+
+	invalid := func(reason string) (models.OAuthClient, string, error) {
+		return models.OAuthClient{}, "", fmt.Errorf("%s: %w", op, &OAuthClientError{Reason: reason})
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return invalid("name is required")
+	}
+	if len(grantTypes) == 0 {
+		grantTypes = []string{GrantAuthorizationCode}
+	}
+	for _, grantType := range grantTypes {
+		if grantType != GrantAuthorizationCode && grantType != GrantClientCredentials {
+			return invalid(fmt.Sprintf("unsupported grant type %q", grantType))
+		}
+	}
+	// redirect_uri нужен только для authorization_code
+	if slices.Contains(grantTypes, GrantAuthorizationCode) != (len(redirectURIs) > 0) {
+		return invalid("redirect uris are required for authorization_code and only for it")
+	}
+	for _, uri := range redirectURIs {
+		if err := checkRedirectURI(uri); err != nil {
+			return invalid(fmt.Sprintf("redirect uri %q: %v", uri, err))
+		}
+	}
+	for _, scope := range scopes {
+		if _, ok := OAuthScopes[scope]; !ok {
+			return invalid(fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	client := models.OAuthClient{
+		ID:           uuid.NewString(),
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       slices.Compact(slices.Sorted(slices.Values(scopes))),
+		GrantTypes:   slices.Compact(slices.Sorted(slices.Values(grantTypes))),
+		CreatedAt:    time.Now(),
+	}
+	var secret string
+	if slices.Contains(grantTypes, GrantClientCredentials) {
+		var err error
+		if secret, err = generateClientSecret(); err != nil {
+			a.log.Error("failed to generate client secret", slog.String("error", err.Error()))
+			return models.OAuthClient{}, "", fmt.Errorf("%s: %w", op, err)
+		}
+		client.SecretHash = a.hashToken(secret)
+	}
+
+	if err := a.oauthRepo.SaveOAuthClient(ctx, client); err != nil {
+		a.log.Error("failed to save oauth client", slog.String("error", err.Error()))
+		return models.OAuthClient{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("oauth client created",
+		slog.String("clientID", client.ID),
+		slog.String("name", client.Name),
+		slog.String("grantTypes", strings.Join(client.GrantTypes, " ")),
+	)
+
+	return client, secret, nil
+}
+
+// DeleteOAuthClient deletes the client and revokes every token issued to it
+func (a *Auth) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	const op = "auth.DeleteOAuthClient"
+	log := a.log.With(slog.String("op", op), slog.String("clientID", clientID))
+
+	// access токены ищутся по refresh токенам клиента, поэтому до удаления
+	if err := a.revoker.RevokeClient(ctx, clientID); err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.oauthRepo.DeleteOAuthClient(ctx, clientID); err != nil {
+		if errors.Is(err, storage.ErrOAuthClientNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrOAuthClientNotFound)
+		}
+		a.log.Error("failed to delete oauth client", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("oauth client deleted")
+
+	return nil
+}
+
+// Authorize validates the authorization request and returns the consent page URL the browser is sent to.
+// redirect_uri is required and must exactly match a registered one, only S256 PKCE is accepted.
+func (a *Auth) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	const op = "auth.Authorize"
+	log := a.log.With(slog.String("op", op), slog.String("clientID", req.ClientID))
+
+	client, err := a.oauthRepo.OAuthClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOAuthClientNotFound) {
+			log.Warn("authorization request for unknown client")
+			return "", &OAuthError{Code: OAuthInvalidRequest, Description: "unknown client_id"}
+		}
+		a.log.Error("failed to get oauth client", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	// на незарегистрированный адрес не редиректим даже ошибку
+	if !slices.Contains(client.GrantTypes, GrantAuthorizationCode) || !redirectURIAllowed(client, req.RedirectURI) {
+		log.Warn("authorization request with unregistered redirect uri", slog.String("redirectURI", req.RedirectURI))
+		return "", &OAuthError{Code: OAuthInvalidRequest, Description: "redirect_uri is not registered for the client"}
+	}
+
+	fail := func(code, description string) error {
+		return &OAuthError{Code: code, Description: description, Redirect: errorRedirect(req.RedirectURI, code, description, req.State)}
+	}
+	if req.ResponseType != "code" {
+		return "", fail(OAuthUnsupportedResponseType, "only response_type=code is supported")
+	}
+	if req.CodeChallengeMethod != "S256" {
+		return "", fail(OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+	if !validCodeChallenge(req.CodeChallenge) {
+		return "", fail(OAuthInvalidRequest, "invalid code_challenge")
+	}
+	scopes, ok := requestedScopes(client, req.Scope)
+	if !ok {
+		return "", fail(OAuthInvalidScope, "requested scope is not allowed for the client")
+	}
+	// пользователь не может делегировать права сервиса
+	if req.Scope == "" {
+		scopes = slices.DeleteFunc(slices.Clone(scopes), func(s string) bool { return s == ScopeIntrospect })
+	} else if slices.Contains(scopes, ScopeIntrospect) {
+		return "", fail(OAuthInvalidScope, "scope introspect is available only with client_credentials")
+	}
+
+	requestID := uuid.NewString()
+	err = a.oauthRepo.SaveAuthorizationRequest(ctx, a.hashToken(requestID), models.AuthorizationRequest{
+		ClientID:      client.ID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		State:         req.State,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(a.oauth.RequestTTL),
+	})
+	if err != nil {
+		a.log.Error("failed to save authorization request", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	consent, err := url.Parse(a.oauth.ConsentURL)
+	if err != nil {
+		a.log.Error("invalid oauth consent url", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	query := consent.Query()
+	query.Set("request_id", requestID)
+	consent.RawQuery = query.Encode()
+
+	return consent.String(), nil
+}
+
+// AuthorizationRequest returns the pending request and its client for the consent page
+func (a *Auth) AuthorizationRequest(ctx context.Context, requestID string) (models.AuthorizationRequest, models.OAuthClient, error) {
+	const op = "auth.AuthorizationRequest"
+
+	req, err := a.oauthRepo.AuthorizationRequest(ctx, a.hashToken(requestID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthorizationRequestNotFound) {
+			return models.AuthorizationRequest{}, models.OAuthClient{}, fmt.Errorf("%s: %w", op, ErrAuthorizationRequestNotFound)
+		}
+		a.log.Error("failed to get authorization request", slog.String("error", err.Error()))
+		return models.AuthorizationRequest{}, models.OAuthClient{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	client, err := a.oauthRepo.OAuthClient(ctx, req.ClientID)
+	if err != nil {
+		a.log.Error("failed to get oauth client", slog.String("error", err.Error()))
+		return models.AuthorizationRequest{}, models.OAuthClient{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return req, client, nil
+}
+
+// Consent records the decision of the user logged in with accessToken and returns the client redirect:
+// with an authorization code if approved, with error=access_denied otherwise.
+// Only first-party tokens may consent, a client cannot grant itself access.
+func (a *Auth) Consent(ctx context.Context, accessToken, requestID string, approve bool) (string, error) {
+	const op = "auth.Consent"
+	log := a.log.With(slog.String("op", op))
+
+	token, err := a.Introspect(ctx, accessToken)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !token.Active || token.ClientID != "" {
+		log.Warn("consent with invalid or client token")
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	log = log.With(slog.Int64("userID", token.UserID))
+
+	req, err := a.oauthRepo.TakeAuthorizationRequest(ctx, a.hashToken(requestID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthorizationRequestNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrAuthorizationRequestNotFound)
+		}
+		a.log.Error("failed to get authorization request", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("clientID", req.ClientID))
+
+	if !approve {
+		log.Info("oauth consent denied")
+		return errorRedirect(req.RedirectURI, OAuthAccessDenied, "the user denied the request", req.State), nil
+	}
+
+	code := uuid.NewString()
+	err = a.oauthRepo.SaveAuthorizationCode(ctx, a.hashToken(code), models.AuthorizationCode{
+		ClientID:      req.ClientID,
+		UserID:        token.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(a.oauth.CodeTTL),
+	})
+	if err != nil {
+		a.log.Error("failed to save authorization code", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: oauth consent granted",
+		slog.String("event", "oauth_consent_granted"),
+		slog.String("scope", strings.Join(req.Scopes, " ")),
+	)
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params), nil
+}
+
+// ExchangeAuthorizationCode exchanges a code for a token pair of a new session of the client.
+// Each code is used once, presenting it again revokes the session it started.
+func (a *Auth) ExchangeAuthorizationCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string, info models.SessionInfo) (OAuthTokens, error) {
+	const op = "auth.ExchangeAuthorizationCode"
+	log := a.log.With(slog.String("op", op), slog.String("clientID", clientID))
+
+	if _, err := a.authenticateClient(ctx, log, clientID, clientSecret, GrantAuthorizationCode); err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidGrant := &OAuthError{Code: OAuthInvalidGrant, Description: "invalid or expired authorization code"}
+
+	// код проверяется до использования: чужой или неверный запрос не должен его сжечь
+	codeHash := a.hashToken(code)
+	authCode, err := a.oauthRepo.AuthorizationCode(ctx, codeHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthorizationCodeNotFound) {
+			log.Warn("authorization code not found")
+			return OAuthTokens{}, invalidGrant
+		}
+		a.log.Error("failed to get authorization code", slog.String("error", err.Error()))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", authCode.UserID))
+	switch {
+	case authCode.ClientID != clientID:
+		log.Warn("authorization code presented by another client")
+		return OAuthTokens{}, invalidGrant
+	case authCode.UsedAt != nil:
+		if err := a.revokeReusedCode(ctx, log, authCode); err != nil {
+			return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+		}
+		return OAuthTokens{}, invalidGrant
+	case time.Now().After(authCode.ExpiresAt):
+		log.Info("authorization code expired")
+		return OAuthTokens{}, invalidGrant
+	case authCode.RedirectURI != redirectURI:
+		log.Warn("authorization code presented with another redirect uri")
+		return OAuthTokens{}, invalidGrant
+	case !verifyCodeChallenge(codeVerifier, authCode.CodeChallenge):
+		log.Warn("invalid pkce code verifier")
+		return OAuthTokens{}, invalidGrant
+	}
+
+	familyID := uuid.NewString()
+	authCode, err = a.oauthRepo.UseAuthorizationCode(ctx, codeHash, authCode, familyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrAuthorizationCodeNotFound):
+			log.Info("authorization code expired")
+			return OAuthTokens{}, invalidGrant
+		case errors.Is(err, storage.ErrAuthorizationCodeUsed):
+			if err := a.revokeReusedCode(ctx, log, authCode); err != nil {
+				return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+			}
+			return OAuthTokens{}, invalidGrant
+		}
+		a.log.Error("failed to use authorization code", slog.String("error", err.Error()))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// пока клиент обменивал код, аккаунт могли заблокировать
+	user, err := a.userProvider.UserByID(ctx, authCode.UserID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.checkLoginAllowed(log, user); err != nil {
+		return OAuthTokens{}, invalidGrant
+	}
+
+	grant := models.OAuthGrant{ClientID: clientID, Scopes: authCode.Scopes}
+	accessToken, refreshToken, err := a.startFamily(ctx, user.ID, familyID, info, grant)
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("authorization code exchanged", slog.String("familyID", familyID))
+
+	return OAuthTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: a.accessTTL, Scopes: grant.Scopes}, nil
+}
+
+// revokeReusedCode revokes the session started by the first exchange of the code (RFC 6749, 4.1.2)
+func (a *Auth) revokeReusedCode(ctx context.Context, log *slog.Logger, code models.AuthorizationCode) error {
+	log.Warn("security event: authorization code reuse detected, revoking session",
+		slog.String("event", "authorization_code_reuse"),
+		slog.String("familyID", code.FamilyID),
+	)
+	if code.FamilyID == "" {
+		return nil
+	}
+	return a.revokeFamily(ctx, code.FamilyID)
+}
+
+// RefreshOAuthToken rotates a refresh token issued to the client. The access token gets the requested scope
+// (not wider than granted), the new refresh token keeps all granted scopes.
+func (a *Auth) RefreshOAuthToken(ctx context.Context, clientID, clientSecret, refreshToken, scope string, info models.SessionInfo) (OAuthTokens, error) {
+	const op = "auth.RefreshOAuthToken"
+	log := a.log.With(slog.String("op", op), slog.String("clientID", clientID))
+
+	if _, err := a.authenticateClient(ctx, log, clientID, clientSecret, GrantAuthorizationCode); err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidGrant := &OAuthError{Code: OAuthInvalidGrant, Description: "invalid or expired refresh token"}
+
+	refreshData, err := a.refreshTokenData(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return OAuthTokens{}, invalidGrant
+		}
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.Int64("userID", refreshData.UserID), slog.String("familyID", refreshData.FamilyID))
+
+	if refreshData.Grant.ClientID != clientID {
+		log.Warn("refresh token presented by another client")
+		return OAuthTokens{}, invalidGrant
+	}
+	// access токен можно запросить с частью выданных scope'ов (RFC 6749, 6), refresh токен сохраняет весь grant
+	accessGrant := refreshData.Grant
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, s := range requested {
+			if !slices.Contains(refreshData.Grant.Scopes, s) {
+				return OAuthTokens{}, &OAuthError{Code: OAuthInvalidScope, Description: "requested scope exceeds the granted one"}
+			}
+		}
+		accessGrant.Scopes = slices.Compact(slices.Sorted(slices.Values(requested)))
+	}
+
+	accessToken, newRefreshToken, err := a.rotateRefreshToken(ctx, log, refreshToken, refreshData, accessGrant, info)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenReused) {
+			return OAuthTokens{}, invalidGrant
+		}
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("successfully refreshed oauth tokens")
+
+	return OAuthTokens{AccessToken: accessToken, RefreshToken: newRefreshToken, ExpiresIn: a.accessTTL, Scopes: accessGrant.Scopes}, nil
+}
+
+// ClientCredentials issues an access token to the client itself, without a user and a refresh token
+func (a *Auth) ClientCredentials(ctx context.Context, clientID, clientSecret, scope string) (OAuthTokens, error) {
+	const op = "auth.ClientCredentials"
+	log := a.log.With(slog.String("op", op), slog.String("clientID", clientID))
+
+	client, err := a.authenticateClient(ctx, log, clientID, clientSecret, GrantClientCredentials)
+	if err != nil {
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	scopes, ok := requestedScopes(client, scope)
+	if !ok {
+		log.Warn("client requested scope it is not allowed")
+		return OAuthTokens{}, &OAuthError{Code: OAuthInvalidScope, Description: "requested scope is not allowed for the client"}
+	}
+
+	access := models.AccessToken{ID: uuid.NewString()}
+	token, err := a.keys.GenerateClientToken(client.ID, access.ID, scopes, a.accessTTL)
+	if err != nil {
+		a.log.Error("failed to generate token", slog.String("error", err.Error()))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	access.ExpiresAt = time.Now().Add(a.accessTTL)
+	if err := a.oauthRepo.SaveClientAccessToken(ctx, client.ID, access); err != nil {
+		a.log.Error("failed to save client access token", slog.String("error", err.Error()))
+		return OAuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("client credentials token issued", slog.String("scope", strings.Join(scopes, " ")))
+
+	return OAuthTokens{AccessToken: token, ExpiresIn: a.accessTTL, Scopes: scopes}, nil
+}
+
+// AuthenticateIntrospectionClient checks the credentials of a client calling the introspection endpoint:
+// a confidential client_credentials client with scope introspect (RFC 7662, 2.1)
+func (a *Auth) AuthenticateIntrospectionClient(ctx context.Context, clientID, clientSecret string) error {
+	const op = "auth.AuthenticateIntrospectionClient"
+	log := a.log.With(slog.String("op", op), slog.String("clientID", clientID))
+
+	client, err := a.authenticateClient(ctx, log, clientID, clientSecret, GrantClientCredentials)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if client.SecretHash == "" || !slices.Contains(client.Scopes, ScopeIntrospect) {
+		log.Warn("client is not allowed to introspect tokens")
+		return fmt.Errorf("%s: %w", op, &OAuthError{Code: OAuthUnauthorizedClient, Description: "client is not allowed to introspect tokens"})
+	}
+
+	return nil
+}
+
+// authenticateClient checks the client secret if the client has one and that the grant type is allowed.
+// Public clients must not send a secret, confidential ones must.
+func (a *Auth) authenticateClient(ctx context.Context, log *slog.Logger, clientID, clientSecret, grantType string) (models.OAuthClient, error) {
+	invalidClient := &OAuthError{Code: OAuthInvalidClient, Description: "client authentication failed"}
+
+	client, err := a.oauthRepo.OAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOAuthClientNotFound) {
+			log.Warn("unknown oauth client")
+			return models.OAuthClient{}, invalidClient
+		}
+		a.log.Error("failed to get oauth client", slog.String("error", err.Error()))
+		return models.OAuthClient{}, err
+	}
+
+	if client.SecretHash == "" {
+		if clientSecret != "" {
+			log.Warn("secret presented for public oauth client")
+			return models.OAuthClient{}, invalidClient
+		}
+	} else if subtle.ConstantTimeCompare([]byte(a.hashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		log.Warn("security event: invalid oauth client secret", slog.String("event", "oauth_client_auth_failed"))
+		return models.OAuthClient{}, invalidClient
+	}
+
+	if !slices.Contains(client.GrantTypes, grantType) {
+		log.Warn("grant type is not allowed for the client", slog.String("grantType", grantType))
+		return models.OAuthClient{}, &OAuthError{Code: OAuthUnauthorizedClient, Description: "grant type is not allowed for the client"}
+	}
+
+	return client, nil
+}
+
+func (a *Auth) revokeFamily(ctx context.Context, familyID string) error {
+	if err := a.tokenRepo.RevokeTokenFamily(ctx, familyID); err != nil {
+		a.log.Error("failed to revoke token family", slog.String("error", err.Error()))
+		return err
+	}
+	if err := a.revoker.RevokeSession(ctx, familyID); err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// checkRedirectURI разрешает https, http только на loopback (RFC 8252, 7.3) и private-use схемы
+// вида com.example.app (RFC 8252, 7.1). Фрагмент запрещён (RFC 6749, 3.1.2).
+func checkRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	if u.Fragment != "" || strings.Contains(uri, "#") {
+		return errors.New("fragment is not allowed")
+	}
+	if u.User != nil {
+		return errors.New("userinfo is not allowed")
+	}
+
+	switch u.Scheme {
+	case "https":
+		if u.Host == "" {
+			return errors.New("host is required")
+		}
+	case "http":
+		if !isLoopback(u.Hostname()) {
+			return errors.New("http is allowed only for loopback addresses")
+		}
+	case "":
+		return errors.New("absolute uri is required")
+	default:
+		if !strings.Contains(u.Scheme, ".") {
+			return errors.New("private-use scheme must be a reverse domain name")
+		}
+	}
+	return nil
+}
+
+// redirectURIAllowed сравнивает адрес с зарегистрированными посимвольно,
+// для loopback порт может отличаться (RFC 8252, 7.3)
+func redirectURIAllowed(client models.OAuthClient, uri string) bool {
+	if uri == "" {
+		return false
+	}
+	if slices.Contains(client.RedirectURIs, uri) {
+		return true
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "http" || !isLoopback(u.Hostname()) {
+		return false
+	}
+	for _, registered := range client.RedirectURIs {
+		r, err := url.Parse(registered)
+		if err != nil || r.Scheme != "http" || r.Hostname() != u.Hostname() {
+			continue
+		}
+		if r.EscapedPath() == u.EscapedPath() && r.RawQuery == u.RawQuery {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requestedScopes без scope выдаёт все scope'ы клиента
+func requestedScopes(client models.OAuthClient, scope string) ([]string, bool) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return client.Scopes, true
+	}
+	for _, s := range requested {
+		if !slices.Contains(client.Scopes, s) {
+			return nil, false
+		}
+	}
+	return slices.Compact(slices.Sorted(slices.Values(requested))), true
+}
+
+// validCodeChallenge: S256 challenge это base64url без паддинга от SHA-256, ровно 43 символа
+func validCodeChallenge(challenge string) bool {
+	if len(challenge) != 43 {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil
+}
+
+// verifyCodeChallenge проверяет code_verifier (RFC 7636, 4.1 и 4.6)
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("-._~", c)) {
+			return false
+		}
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// generateClientSecret 256 бит в base64url
+func generateClientSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func errorRedirect(redirectURI, code, description, state string) string {
+	params := url.Values{"error": {code}, "error_description": {description}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	return withQuery(redirectURI, params)
+}
+
+// withQuery добавляет параметры, сохраняя query зарегистрированного redirect_uri
+func withQuery(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
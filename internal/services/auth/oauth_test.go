@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mJ92K1GqSERr4K3HUsIQ_Kv6VLAIq8"
+	challenge := s256(verifier)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{name: "matching verifier", verifier: verifier, challenge: challenge, want: true},
+		{name: "fixed vector", verifier: verifier, challenge: "egWjYWipIVZqOLrISWBGAdbGoA-STOBnDNZmc2J2Y0o", want: true},
+		{name: "other verifier", verifier: strings.Repeat("a", 43), challenge: challenge},
+		{name: "plain method is not accepted", verifier: verifier, challenge: verifier},
+		{name: "challenge with padding", verifier: verifier, challenge: challenge + "="},
+		{name: "empty challenge", verifier: verifier, challenge: ""},
+		{name: "verifier too short", verifier: strings.Repeat("a", 42), challenge: s256(strings.Repeat("a", 42))},
+		{name: "shortest verifier", verifier: strings.Repeat("a", 43), challenge: s256(strings.Repeat("a", 43)), want: true},
+		{name: "longest verifier", verifier: strings.Repeat("a", 128), challenge: s256(strings.Repeat("a", 128)), want: true},
+		{name: "verifier too long", verifier: strings.Repeat("a", 129), challenge: s256(strings.Repeat("a", 129))},
+		{name: "unreserved characters", verifier: strings.Repeat("aZ9-._~", 7), challenge: s256(strings.Repeat("aZ9-._~", 7)), want: true},
+		{name: "forbidden character", verifier: strings.Repeat("a", 42) + "+", challenge: s256(strings.Repeat("a", 42) + "+")},
+		{name: "non ascii", verifier: strings.Repeat("a", 42) + "й", challenge: s256(strings.Repeat("a", 42) + "й")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyCodeChallenge(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("verifyCodeChallenge(%q, %q) = %v, want %v", tt.verifier, tt.challenge, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		want      bool
+	}{
+		{name: "S256 challenge", challenge: s256("verifier"), want: true},
+		{name: "too short", challenge: s256("verifier")[:42]},
+		{name: "with padding", challenge: s256("verifier") + "="},
+		{name: "standard base64 alphabet", challenge: strings.Repeat("+", 43)},
+		{name: "empty", challenge: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validCodeChallenge(tt.challenge); got != tt.want {
+				t.Errorf("validCodeChallenge(%q) = %v, want %v", tt.challenge, got, tt.want)
+			}
+		})
+	}
+}
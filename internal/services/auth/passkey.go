@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/webauthn"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrInvalidPasskey = errors.New("invalid passkey")
+	ErrPasskeyExists  = errors.New("passkey already registered")
+)
+
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+type PasskeyRepo interface {
+	SavePasskey(ctx context.Context, passkey models.Passkey) error
+	Passkey(ctx context.Context, credentialID []byte) (models.Passkey, error)
+	UserPasskeys(ctx context.Context, userID int64) ([]models.Passkey, error)
+	UpdatePasskeyUsage(ctx context.Context, credentialID []byte, signCount uint32) error
+	SaveWebAuthnChallenge(ctx context.Context, challenge []byte, kind string, userID int64, expiresAt time.Time) error
+	TakeWebAuthnChallenge(ctx context.Context, challenge []byte, kind string) (int64, error)
+}
+
+// BeginPasskeyRegistration starts registration of a new passkey for the logged in user
+func (a *Auth) BeginPasskeyRegistration(ctx context.Context, userID int64) (models.PasskeyCreationOptions, error) {
+	const op = "auth.BeginPasskeyRegistration"
+
+	user, err := a.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return models.PasskeyCreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// уже зарегистрированные ключи аутентификатор не будет создавать повторно
+	passkeys, err := a.passkeys.UserPasskeys(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to get passkeys", slog.String("error", err.Error()))
+		return models.PasskeyCreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+	exclude := make([][]byte, len(passkeys))
+	for i, passkey := range passkeys {
+		exclude[i] = passkey.ID
+	}
+
+	challenge, err := a.newCeremony(ctx, ceremonyRegistration, userID)
+	if err != nil {
+		a.log.Error("failed to save webauthn challenge", slog.String("error", err.Error()))
+		return models.PasskeyCreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.PasskeyCreationOptions{
+		Challenge:          challenge,
+		RPID:               a.rp.ID,
+		RPName:             a.rp.Name,
+		UserHandle:         userHandle(userID),
+		UserName:           user.Username,
+		UserDisplayName:    user.FIO,
+		Algorithms:         webauthn.Algorithms,
+		ExcludeCredentials: exclude,
+		Timeout:            a.rp.Timeout,
+		UserVerification:   a.rp.UserVerification(),
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the attestation and stores the credential
+func (a *Auth) FinishPasskeyRegistration(ctx context.Context, userID int64, name string, clientDataJSON, attestationObject []byte) ([]byte, error) {
+	const op = "auth.FinishPasskeyRegistration"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	challenge, err := a.takeCeremony(ctx, ceremonyRegistration, clientDataJSON)
+	if err != nil {
+		log.Warn("unknown webauthn challenge", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if challenge.userID != userID {
+		log.Warn("webauthn challenge was issued for another user")
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidPasskey)
+	}
+
+	credential, err := a.rp.VerifyRegistration(challenge.value, clientDataJSON, attestationObject)
+	if err != nil {
+		log.Warn("passkey attestation rejected", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidPasskey, err)
+	}
+
+	err = a.passkeys.SavePasskey(ctx, models.Passkey{
+		ID:        credential.ID,
+		UserID:    userID,
+		Name:      name,
+		PublicKey: credential.PublicKey,
+		SignCount: credential.SignCount,
+		AAGUID:    credential.AAGUID,
+		Format:    credential.Format,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrPasskeyExists) {
+			return nil, fmt.Errorf("%s: %w", op, ErrPasskeyExists)
+		}
+		a.log.Error("failed to save passkey", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: passkey registered", slog.String("event", "passkey_registered"), slog.String("format", credential.Format))
+
+	return credential.ID, nil
+}
+
+// BeginPasskeyLogin starts passwordless login. Without login the authenticator offers
+// discoverable credentials, with login only credentials of that user are allowed.
+func (a *Auth) BeginPasskeyLogin(ctx context.Context, login string) (models.PasskeyRequestOptions, error) {
+	const op = "auth.BeginPasskeyLogin"
+
+	var userID int64
+	var allow [][]byte
+	if login != "" {
+		user, err := a.userByLogin(ctx, login)
+		switch {
+		case err == nil:
+			userID = user.ID
+		case !errors.Is(err, storage.ErrUserNotFound):
+			a.log.Error("failed to get user", slog.String("error", err.Error()))
+			return models.PasskeyRequestOptions{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// для несуществующего пользователя ответ такой же, как для пользователя без ключей
+		if userID != 0 {
+			passkeys, err := a.passkeys.UserPasskeys(ctx, userID)
+			if err != nil {
+				a.log.Error("failed to get passkeys", slog.String("error", err.Error()))
+				return models.PasskeyRequestOptions{}, fmt.Errorf("%s: %w", op, err)
+			}
+			for _, passkey := range passkeys {
+				allow = append(allow, passkey.ID)
+			}
+		}
+	}
+
+	challenge, err := a.newCeremony(ctx, ceremonyLogin, userID)
+	if err != nil {
+		a.log.Error("failed to save webauthn challenge", slog.String("error", err.Error()))
+		return models.PasskeyRequestOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             a.rp.ID,
+		AllowCredentials: allow,
+		Timeout:          a.rp.Timeout,
+		UserVerification: a.rp.UserVerification(),
+	}, nil
+}
+
+// FinishPasskeyLogin verifies the assertion and issues the same token pair as Login.
+// If the authenticator did not verify the user, enabled TOTP is still required.
+func (a *Auth) FinishPasskeyLogin(ctx context.Context, assertion models.PasskeyAssertion, info models.SessionInfo) (string, string, error) {
+	const op = "auth.FinishPasskeyLogin"
+	log := a.log.With(slog.String("op", op))
+
+	challenge, err := a.takeCeremony(ctx, ceremonyLogin, assertion.ClientDataJSON)
+	if err != nil {
+		log.Warn("unknown webauthn challenge", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	passkey, err := a.passkeys.Passkey(ctx, assertion.CredentialID)
+	if err != nil {
+		if errors.Is(err, storage.ErrPasskeyNotFound) {
+			log.Warn("unknown passkey presented")
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidPasskey)
+		}
+		a.log.Error("failed to get passkey", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", passkey.UserID))
+
+	if challenge.userID != 0 && challenge.userID != passkey.UserID {
+		log.Warn("passkey does not belong to the user the challenge was issued for")
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidPasskey)
+	}
+	if assertion.UserHandle != nil && string(assertion.UserHandle) != string(userHandle(passkey.UserID)) {
+		log.Warn("user handle does not match passkey owner")
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidPasskey)
+	}
+
+	result, err := a.rp.VerifyAssertion(challenge.value, webauthn.Credential{
+		ID:        passkey.ID,
+		PublicKey: passkey.PublicKey,
+		SignCount: passkey.SignCount,
+	}, assertion.ClientDataJSON, assertion.AuthenticatorData, assertion.Signature)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCountRollback) {
+			log.Warn("security event: passkey sign count rollback, authenticator may be cloned",
+				slog.String("event", "passkey_clone_suspected"),
+			)
+		} else {
+			log.Warn("passkey assertion rejected", slog.String("error", err.Error()))
+		}
+		return "", "", fmt.Errorf("%s: %w: %v", op, ErrInvalidPasskey, err)
+	}
+
+	if err := a.passkeys.UpdatePasskeyUsage(ctx, passkey.ID, result.SignCount); err != nil {
+		if errors.Is(err, storage.ErrPasskeySignCount) {
+			// тот же счетчик уже принят в параллельном входе
+			log.Warn("security event: passkey sign count rollback, authenticator may be cloned",
+				slog.String("event", "passkey_clone_suspected"),
+			)
+			return "", "", fmt.Errorf("%s: %w: %v", op, ErrInvalidPasskey, err)
+		}
+		a.log.Error("failed to update passkey", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.userProvider.UserByID(ctx, passkey.UserID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.checkLoginAllowed(log, user); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// ключ без проверки пользователя (PIN, биометрия) - только один фактор
+	if !result.UserVerified {
+		if err := a.mfaChallenge(ctx, user.ID); err != nil {
+			if errors.Is(err, ErrMFARequired) {
+				log.Info("second factor required")
+				return "", "", fmt.Errorf("%s: %w", op, err)
+			}
+			a.log.Error("failed to check mfa", slog.String("error", err.Error()))
+			return "", "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	accessToken, refreshToken, err := a.startSession(ctx, user.ID, info)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("successfully logged in with passkey")
+
+	return accessToken, refreshToken, nil
+}
+
+type ceremony struct {
+	value  []byte
+	userID int64
+}
+
+func (a *Auth) newCeremony(ctx context.Context, kind string, userID int64) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.passkeys.SaveWebAuthnChallenge(ctx, challenge, kind, userID, time.Now().Add(a.rp.Timeout)); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// takeCeremony finds the challenge the client signed and consumes it
+func (a *Auth) takeCeremony(ctx context.Context, kind string, clientDataJSON []byte) (ceremony, error) {
+	challenge, err := webauthn.ChallengeFromClientData(clientDataJSON)
+	if err != nil {
+		return ceremony{}, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	userID, err := a.passkeys.TakeWebAuthnChallenge(ctx, challenge, kind)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebAuthnChallengeMissing) {
+			return ceremony{}, ErrInvalidPasskey
+		}
+		return ceremony{}, err
+	}
+
+	return ceremony{value: challenge, userID: userID}, nil
+}
+
+// userHandle identifies the user inside discoverable credentials, it must not contain personal data
+func userHandle(userID int64) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
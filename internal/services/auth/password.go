@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/mailer"
+	"github.com/AronditFire/User-Service/internal/lib/passpolicy"
+	"github.com/AronditFire/User-Service/internal/storage"
+	val "github.com/AronditFire/User-Service/internal/validator"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrWrongPassword     = errors.New("current password is incorrect")
+	ErrSamePassword      = errors.New("new password must differ from the current one")
+	ErrWeakPassword      = errors.New("password does not satisfy the password policy")
+)
+
+// PasswordPolicyError перечисляет нарушенные правила, errors.Is(err, ErrWeakPassword) == true
+type PasswordPolicyError struct {
+	Violations []passpolicy.Violation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return ErrWeakPassword.Error()
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+type PasswordResetOptions struct {
+	TokenTTL time.Duration
+	LinkURL  string // страница фронтенда, токен добавляется параметром token
+}
+
+type PasswordRepo interface {
+	SavePasswordResetToken(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error
+	PasswordResetUserID(ctx context.Context, tokenHash string) (int64, error)
+	ResetPassword(ctx context.Context, tokenHash string, passHash string) (int64, error)
+	ChangePassword(ctx context.Context, userID int64, passHash, keepSessionID string) ([]string, error)
+	RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) (bool, error)
+}
+
+// RequestPasswordReset emails a reset link if the address belongs to a user.
+// The result and the response time do not depend on whether the user exists.
+func (a *Auth) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "auth.RequestPasswordReset"
+	log := a.log.With(slog.String("op", op))
+
+	user, err := a.userProvider.UserByEmail(ctx, val.NormalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("password reset requested for unknown email")
+			return nil
+		}
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if user.BlockedAt != nil {
+		log.Warn("password reset requested for blocked user", slog.Int64("userID", user.ID))
+		return nil
+	}
+
+	// письмо отправляется в фоне, иначе по времени ответа видно, что пользователь существует
+	go a.sendPasswordReset(context.WithoutCancel(ctx), log.With(slog.Int64("userID", user.ID)), user)
+
+	return nil
+}
+
+// ResetPassword sets a new password and logs the user out everywhere
+func (a *Auth) ResetPassword(ctx context.Context, token, newPassword string) error {
+	const op = "auth.ResetPassword"
+	log := a.log.With(slog.String("op", op))
+	tokenHash := a.hashToken(token)
+
+	// токен проверяется дважды: здесь, чтобы знать пользователя для политики паролей, и при сбросе
+	userID, err := a.passwords.PasswordResetUserID(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrResetTokenNotFound) {
+			log.Warn("invalid password reset token")
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+		a.log.Error("failed to get password reset token", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := a.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.checkPasswordPolicy(newPassword, user); err != nil {
+		log.Info("password rejected by policy", slog.Int64("userID", userID))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.hashPassword(newPassword)
+	if err != nil {
+		a.log.Error("failed to generate password hash", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, err = a.passwords.ResetPassword(ctx, tokenHash, passHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrResetTokenNotFound) {
+			log.Warn("invalid password reset token")
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+		a.log.Error("failed to reset password", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", userID))
+
+	// refresh токены отозваны в той же транзакции, осталось закрыть выданные access токены
+	if err := a.revoker.RevokeUser(ctx, userID); err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: password reset", slog.String("event", "password_reset"))
+
+	return nil
+}
+
+// ChangePassword replaces the password of the logged in user and revokes other sessions.
+// With keepCurrent the session sessionID stays logged in, otherwise the user is logged out everywhere.
+// Wrong current passwords are counted like failed logins of the account.
+func (a *Auth) ChangePassword(ctx context.Context, userID int64, sessionID, currentPassword, newPassword string, keepCurrent bool) error {
+	const op = "auth.ChangePassword"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	user, err := a.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// украденный access токен не должен давать перебирать пароль без ограничений
+	accountKey := userLockoutKey(userID)
+	attempt, err := a.acquireLoginAttempt(ctx, accountKey, a.lockout.FreeAttempts)
+	if err != nil {
+		log.Warn("password change throttled", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ok, err := a.hasher.Verify(currentPassword, string(user.PassHash))
+	if err != nil {
+		a.log.Error("failed to verify password", slog.String("error", err.Error()))
+		a.refundLoginAttempt(ctx, log, accountKey)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("wrong current password on password change")
+		a.lockIfExceeded(ctx, log, accountKey, attempt, a.lockout.AccountLockThreshold)
+		return fmt.Errorf("%s: %w", op, ErrWrongPassword)
+	}
+	if err := a.throttle.ClearLoginFailures(ctx, accountKey, a.lockout.ResetAfter); err != nil {
+		log.Warn("failed to clear login failures", slog.String("error", err.Error()))
+	}
+	if currentPassword == newPassword {
+		return fmt.Errorf("%s: %w", op, ErrSamePassword)
+	}
+	if err := a.checkPasswordPolicy(newPassword, user); err != nil {
+		log.Info("password rejected by policy")
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.hashPassword(newPassword)
+	if err != nil {
+		a.log.Error("failed to generate password hash", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	keepSessionID := ""
+	if keepCurrent {
+		keepSessionID = sessionID
+	}
+	// пароль и refresh токены других сессий меняются в одной транзакции
+	revoked, err := a.passwords.ChangePassword(ctx, userID, passHash, keepSessionID)
+	if err != nil {
+		a.log.Error("failed to change password", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// осталось закрыть выданные access токены, как в ResetPassword
+	if keepSessionID == "" {
+		err = a.revoker.RevokeUser(ctx, userID)
+	} else {
+		for _, revokedSessionID := range revoked {
+			if err = a.revoker.RevokeSession(ctx, revokedSessionID); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("security event: password changed",
+		slog.String("event", "password_changed"),
+		slog.String("sessionID", sessionID),
+		slog.Bool("keptCurrent", keepCurrent),
+	)
+
+	return nil
+}
+
+func (a *Auth) sendPasswordReset(ctx context.Context, log *slog.Logger, user models.User) {
+	token := uuid.NewString()
+	if err := a.passwords.SavePasswordResetToken(ctx, a.hashToken(token), user.ID, time.Now().Add(a.reset.TokenTTL)); err != nil {
+		log.Error("failed to save password reset token", slog.String("error", err.Error()))
+		return
+	}
+
+	link, err := url.Parse(a.reset.LinkURL)
+	if err != nil {
+		log.Error("invalid password reset link url", slog.String("error", err.Error()))
+		return
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	err = a.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Восстановление пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\nСсылка действует %s и может быть использована один раз. Если вы не запрашивали восстановление, просто проигнорируйте это письмо.\n",
+			user.Username, link.String(), a.reset.TokenTTL),
+	})
+	if err != nil {
+		log.Error("failed to send password reset email", slog.String("error", err.Error()))
+		return
+	}
+	log.Info("password reset email sent")
+}
+
+// checkPasswordPolicy is called before every hashPassword of a password chosen by the user
+func (a *Auth) checkPasswordPolicy(password string, user models.User) error {
+	violations, err := a.policy.Check(password, passpolicy.UserInfo{
+		Username: user.Username,
+		Email:    user.Email,
+		FIO:      user.FIO,
+	})
+	if err != nil {
+		a.log.Error("failed to check password policy", slog.String("error", err.Error()))
+		return err
+	}
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// hashPassword is the single place passwords are hashed
+func (a *Auth) hashPassword(password string) (string, error) {
+	return a.hasher.Hash(password)
+}
+
+// rehashPassword upgrades a hash made with an outdated algorithm or parameters after a successful login.
+// Login does not fail if the upgrade fails, the next login will try again.
+func (a *Auth) rehashPassword(ctx context.Context, log *slog.Logger, user models.User, password string) {
+	if !a.hasher.NeedsRehash(string(user.PassHash)) {
+		return
+	}
+
+	passHash, err := a.hashPassword(password)
+	if err != nil {
+		log.Warn("failed to rehash password", slog.String("error", err.Error()))
+		return
+	}
+	// пароль могли сменить параллельно, тогда старый хеш не перезаписывает новый
+	updated, err := a.passwords.RehashPassword(ctx, user.ID, string(user.PassHash), passHash)
+	if err != nil {
+		log.Warn("failed to save rehashed password", slog.String("error", err.Error()))
+		return
+	}
+	if !updated {
+		log.Info("password changed during login, rehash skipped", slog.Int64("userID", user.ID))
+		return
+	}
+	log.Info("password hash upgraded", slog.Int64("userID", user.ID))
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"strings"
+)
+
+// recovery code: 10 символов base32 (50 бит), показывается как xxxxx-xxxxx
+const recoveryCodeLength = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RegenerateRecoveryCodes issues a new set of recovery codes, the previous set stops working
+func (a *Auth) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	const op = "auth.RegenerateRecoveryCodes"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if err := a.checkSecondFactor(ctx, log, userID, code); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	codes, hashes, err := a.generateRecoveryCodes()
+	if err != nil {
+		a.log.Error("failed to generate recovery codes", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		a.log.Error("failed to save recovery codes", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("security event: recovery codes regenerated", slog.String("event", "mfa_recovery_codes_regenerated"))
+
+	return codes, nil
+}
+
+// checkSecondFactor accepts either a code from the authenticator or a recovery code
+func (a *Auth) checkSecondFactor(ctx context.Context, log *slog.Logger, userID int64, code string) error {
+	if isTOTPCode(code) {
+		return a.checkTOTP(ctx, userID, code)
+	}
+	return a.checkRecoveryCode(ctx, log, userID, code)
+}
+
+func (a *Auth) checkRecoveryCode(ctx context.Context, log *slog.Logger, userID int64, code string) error {
+	enabled, err := a.totpEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrMFANotEnabled
+	}
+
+	codes, err := a.mfaRepo.RecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	for _, stored := range codes {
+		if bcrypt.CompareHashAndPassword(stored.Hash, []byte(normalized)) != nil {
+			continue
+		}
+
+		if err := a.mfaRepo.UseRecoveryCode(ctx, stored.ID); err != nil {
+			if errors.Is(err, storage.ErrRecoveryCodeUsed) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		log.Warn("security event: recovery code used",
+			slog.String("event", "mfa_recovery_code_used"),
+			slog.Int("remaining", len(codes)-1),
+		)
+		return nil
+	}
+
+	return ErrInvalidMFACode
+}
+
+// generateRecoveryCodes returns codes to show to the user and their bcrypt hashes to store
+func (a *Auth) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, a.mfa.RecoveryCodes)
+	hashes := make([]string, a.mfa.RecoveryCodes)
+
+	for i := range codes {
+		raw := make([]byte, 8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:recoveryCodeLength]
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = string(hash)
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode allows to enter the code without dash and in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"log/slog"
+)
+
+// ListSessions returns active sessions (token families) of the user
+func (a *Auth) ListSessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "auth.ListSessions"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+	log.Info("fetching user sessions")
+
+	sessions, err := a.tokenRepo.ListSessions(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to list sessions", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("successfully fetched user sessions", slog.Int("count", len(sessions)))
+	return sessions, nil
+}
+
+// RevokeSession revokes one session of the user
+func (a *Auth) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	const op = "auth.RevokeSession"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.String("sessionID", sessionID))
+	log.Info("revoking session")
+
+	if err := a.tokenRepo.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			a.log.Warn("session not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+		}
+		a.log.Error("failed to revoke session", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.revoker.RevokeSession(ctx, sessionID); err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("session successfully revoked")
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere, except keepSessionID if it is not empty
+func (a *Auth) RevokeAllSessions(ctx context.Context, userID int64, keepSessionID string) error {
+	const op = "auth.RevokeAllSessions"
+	log := a.log.With(slog.String("op", op), slog.Int64("userID", userID))
+	log.Info("revoking all sessions")
+
+	// access tokens of the kept session must stay valid, so others are revoked one by one
+	sessions, err := a.tokenRepo.ListSessions(ctx, userID)
+	if err != nil {
+		a.log.Error("failed to list sessions", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.tokenRepo.RevokeAllSessions(ctx, userID, keepSessionID); err != nil {
+		a.log.Error("failed to revoke sessions", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if keepSessionID == "" {
+		err = a.revoker.RevokeUser(ctx, userID)
+	} else {
+		for _, session := range sessions {
+			if session.ID == keepSessionID {
+				continue
+			}
+			if err = a.revoker.RevokeSession(ctx, session.ID); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		a.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("all sessions successfully revoked", slog.Bool("keptCurrent", keepSessionID != ""))
+	return nil
+}
@@ -0,0 +1,203 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// syncOverlap re-reads entries around the cursor, so rows committed out of revoked_at order are not missed
+const syncOverlap = time.Minute
+
+// maxMissedSyncs: после стольких неудачных синхронизаций подряд кэш считается устаревшим
+// и IsRevoked отклоняет все токены (fail closed), отзыв на другой реплике иначе остался бы незамеченным
+const maxMissedSyncs = 3
+
+// Denylist keeps revoked access tokens (by jti) in memory and periodically
+// syncs them from Postgres, so revocations made by other replicas are seen too.
+type Denylist struct {
+	log          *slog.Logger
+	store        Store
+	syncInterval time.Duration
+
+	mu       sync.RWMutex
+	revoked  map[string]time.Time // jti -> access token expiry
+	cursor   time.Time            // revoked_at of the latest synced entry
+	syncedAt time.Time
+
+	syncMu   sync.Mutex
+	degraded atomic.Bool // кэш устарел, токены отклоняются
+}
+
+type Store interface {
+	RevokeAccessToken(ctx context.Context, token models.AccessToken) ([]models.RevokedAccessToken, error)
+	RevokeFamilyAccessTokens(ctx context.Context, familyID string) ([]models.RevokedAccessToken, error)
+	RevokeUserAccessTokens(ctx context.Context, userID int64) ([]models.RevokedAccessToken, error)
+	RevokeClientAccessTokens(ctx context.Context, clientID string) ([]models.RevokedAccessToken, error)
+	RevokedAccessTokens(ctx context.Context, since time.Time) ([]models.RevokedAccessToken, error)
+	PurgeRevokedAccessTokens(ctx context.Context) error
+}
+
+func New(log *slog.Logger, store Store, syncInterval time.Duration) *Denylist {
+	return &Denylist{
+		log:          log,
+		store:        store,
+		syncInterval: syncInterval,
+		revoked:      make(map[string]time.Time),
+	}
+}
+
+// IsRevoked reports whether the access token with given jti was revoked.
+// Читает только кэш; синхронизирует его Run. Если кэш не обновлялся дольше maxMissedSyncs интервалов,
+// любой токен считается отозванным.
+func (d *Denylist) IsRevoked(ctx context.Context, jti string) bool {
+	d.mu.RLock()
+	_, revoked := d.revoked[jti]
+	syncedAt := d.syncedAt
+	d.mu.RUnlock()
+
+	if revoked {
+		return true
+	}
+	if time.Since(syncedAt) > maxMissedSyncs*d.syncInterval {
+		if d.degraded.CompareAndSwap(false, true) {
+			d.log.Error("access token denylist is stale, rejecting all access tokens until the next successful sync",
+				slog.Time("syncedAt", syncedAt))
+		}
+		return true
+	}
+
+	return false
+}
+
+// Run syncs the denylist every syncInterval until ctx is done
+func (d *Denylist) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Sync(ctx); err != nil {
+				d.log.Error("failed to sync access token denylist", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// Sync loads entries revoked since the last sync and drops expired ones.
+// Only one sync runs at a time, concurrent callers return immediately.
+func (d *Denylist) Sync(ctx context.Context) error {
+	const op = "revocation.Sync"
+
+	if !d.syncMu.TryLock() {
+		return nil
+	}
+	defer d.syncMu.Unlock()
+
+	d.mu.RLock()
+	cursor := d.cursor
+	d.mu.RUnlock()
+
+	tokens, err := d.store.RevokedAccessTokens(ctx, cursor.Add(-syncOverlap))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := d.store.PurgeRevokedAccessTokens(ctx); err != nil {
+		d.log.Warn("failed to purge expired denylist entries", slog.String("error", err.Error()))
+	}
+
+	d.add(tokens)
+
+	now := time.Now()
+	d.mu.Lock()
+	for _, token := range tokens {
+		if token.RevokedAt.After(d.cursor) {
+			d.cursor = token.RevokedAt
+		}
+	}
+	for jti, expiresAt := range d.revoked {
+		if now.After(expiresAt) {
+			delete(d.revoked, jti)
+		}
+	}
+	d.syncedAt = now
+	d.mu.Unlock()
+
+	if d.degraded.CompareAndSwap(true, false) {
+		d.log.Info("access token denylist is synced again")
+	}
+
+	return nil
+}
+
+// RevokeToken revokes a single access token
+func (d *Denylist) RevokeToken(ctx context.Context, token models.AccessToken) error {
+	const op = "revocation.RevokeToken"
+
+	tokens, err := d.store.RevokeAccessToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	d.add(tokens)
+
+	return nil
+}
+
+// RevokeSession revokes every access token issued within the session (token family)
+func (d *Denylist) RevokeSession(ctx context.Context, sessionID string) error {
+	const op = "revocation.RevokeSession"
+
+	tokens, err := d.store.RevokeFamilyAccessTokens(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	d.add(tokens)
+
+	d.log.Info("access tokens revoked", slog.String("sessionID", sessionID), slog.Int("count", len(tokens)))
+	return nil
+}
+
+// RevokeUser revokes every access token of the user
+func (d *Denylist) RevokeUser(ctx context.Context, userID int64) error {
+	const op = "revocation.RevokeUser"
+
+	tokens, err := d.store.RevokeUserAccessTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	d.add(tokens)
+
+	d.log.Info("access tokens revoked", slog.Int64("userID", userID), slog.Int("count", len(tokens)))
+	return nil
+}
+
+// RevokeClient revokes every access token issued to the OAuth client
+func (d *Denylist) RevokeClient(ctx context.Context, clientID string) error {
+	const op = "revocation.RevokeClient"
+
+	tokens, err := d.store.RevokeClientAccessTokens(ctx, clientID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	d.add(tokens)
+
+	d.log.Info("access tokens revoked", slog.String("clientID", clientID), slog.Int("count", len(tokens)))
+	return nil
+}
+
+func (d *Denylist) add(tokens []models.RevokedAccessToken) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, token := range tokens {
+		d.revoked[token.ID] = token.ExpiresAt
+	}
+}
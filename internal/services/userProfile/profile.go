@@ -17,17 +17,20 @@ type UserProfile struct {
 	log             *slog.Logger
 	profileProvider ProfileProvider
 	adminFunctions  AdminFunctions
+	revoker         AccessRevoker
 }
 
 func New(
 	log *slog.Logger,
 	profileProvider ProfileProvider,
 	adminFunctions AdminFunctions,
+	revoker AccessRevoker,
 ) *UserProfile {
 	return &UserProfile{
 		log:             log,
 		profileProvider: profileProvider,
 		adminFunctions:  adminFunctions,
+		revoker:         revoker,
 	}
 }
 
@@ -38,6 +41,12 @@ type ProfileProvider interface {
 type AdminFunctions interface {
 	GetAllProfiles(ctx context.Context) ([]models.UserWithRole, error)
 	ChangeRole(ctx context.Context, userID int64, role string) error
+	SetBlocked(ctx context.Context, userID int64, blocked bool) error
+}
+
+// AccessRevoker puts issued access tokens on the denylist
+type AccessRevoker interface {
+	RevokeUser(ctx context.Context, userID int64) error
 }
 
 func (u *UserProfile) GetProfile(ctx context.Context, userID int64) (*models.UserWithRole, error) {
@@ -88,6 +97,54 @@ func (u *UserProfile) ChangeRole(ctx context.Context, userID int64, role string)
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	// токены со старой ролью больше не действуют
+	if err := u.revoker.RevokeUser(ctx, userID); err != nil {
+		u.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	log.Info("Successfully changed user role")
 	return nil
 }
+
+// BlockUser blocks the user and revokes all of their sessions and access tokens
+func (u *UserProfile) BlockUser(ctx context.Context, userID int64) error {
+	const op = "uprofile.BlockUser"
+	log := u.log.With(slog.String("op", op), slog.Int64("userID", userID))
+	log.Info("Blocking user")
+
+	if err := u.adminFunctions.SetBlocked(ctx, userID, true); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			u.log.Warn("user not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		u.log.Error("failed to block user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.revoker.RevokeUser(ctx, userID); err != nil {
+		u.log.Error("failed to revoke access tokens", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Successfully blocked user")
+	return nil
+}
+
+func (u *UserProfile) UnblockUser(ctx context.Context, userID int64) error {
+	const op = "uprofile.UnblockUser"
+	log := u.log.With(slog.String("op", op), slog.Int64("userID", userID))
+	log.Info("Unblocking user")
+
+	if err := u.adminFunctions.SetBlocked(ctx, userID, false); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			u.log.Warn("user not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		u.log.Error("failed to unblock user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Successfully unblocked user")
+	return nil
+}
@@ -0,0 +1,135 @@
+package verification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/tokenhash"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidCode          = errors.New("invalid or expired code")
+	ErrOTPTooFrequent       = errors.New("code was requested too recently")
+	ErrPhoneAlreadyVerified = errors.New("phone number is already verified")
+)
+
+const otpDigits = 6
+
+type PhoneOptions struct {
+	CodeTTL        time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration // не чаще одного SMS за интервал
+}
+
+type PhoneRepo interface {
+	SavePhoneOTP(ctx context.Context, otp models.PhoneOTP, resendInterval time.Duration) error
+	PhoneOTPAttempt(ctx context.Context, userID int64) (models.PhoneOTP, error)
+	DeletePhoneOTP(ctx context.Context, userID int64) error
+	VerifyPhone(ctx context.Context, userID int64, phoneNumber string) error
+}
+
+// RequestPhoneOTP sends a one-time code to the phone number of the user
+func (v *Verification) RequestPhoneOTP(ctx context.Context, userID int64) (time.Time, error) {
+	const op = "verification.RequestPhoneOTP"
+	log := v.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	user, err := v.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		v.log.Error("failed to get user", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.PhoneVerifiedAt != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, ErrPhoneAlreadyVerified)
+	}
+
+	code, err := newOTP()
+	if err != nil {
+		v.log.Error("failed to generate otp", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	expiresAt := time.Now().Add(v.phone.CodeTTL)
+	err = v.phoneRepo.SavePhoneOTP(ctx, models.PhoneOTP{
+		UserID:      userID,
+		PhoneNumber: user.PhoneNumber,
+		CodeHash:    v.hashOTP(userID, code),
+		ExpiresAt:   expiresAt,
+	}, v.phone.ResendInterval)
+	if err != nil {
+		if errors.Is(err, storage.ErrOTPTooFrequent) {
+			log.Warn("otp requested too frequently")
+			return time.Time{}, fmt.Errorf("%s: %w", op, ErrOTPTooFrequent)
+		}
+		v.log.Error("failed to save otp", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	text := fmt.Sprintf("Код подтверждения: %s. Никому его не сообщайте.", code)
+	if err := v.sms.Send(ctx, user.PhoneNumber, text); err != nil {
+		v.log.Error("failed to send sms", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("phone otp sent")
+
+	return expiresAt, nil
+}
+
+// VerifyPhone checks the code and sets phone_verified_at, the code is removed after MaxAttempts
+func (v *Verification) VerifyPhone(ctx context.Context, userID int64, code string) error {
+	const op = "verification.VerifyPhone"
+	log := v.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	otp, err := v.phoneRepo.PhoneOTPAttempt(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOTPNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+		}
+		v.log.Error("failed to get otp", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if otp.Attempts > v.phone.MaxAttempts {
+		log.Warn("security event: too many otp attempts", slog.String("event", "phone_otp_attempts_exceeded"))
+		if err := v.phoneRepo.DeletePhoneOTP(ctx, userID); err != nil {
+			v.log.Error("failed to delete otp", slog.String("error", err.Error()))
+		}
+		return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	if !hmac.Equal([]byte(otp.CodeHash), []byte(v.hashOTP(userID, code))) {
+		log.Info("invalid phone otp", slog.Int("attempt", otp.Attempts))
+		return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	if err := v.phoneRepo.VerifyPhone(ctx, userID, otp.PhoneNumber); err != nil {
+		if errors.Is(err, storage.ErrOTPNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+		}
+		v.log.Error("failed to verify phone", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("phone number verified")
+
+	return nil
+}
+
+// hashOTP binds the code to the user, so equal codes of different users have different hashes
+func (v *Verification) hashOTP(userID int64, code string) string {
+	return tokenhash.Hash(strconv.FormatInt(userID, 10)+":"+code, v.tokenSecret)
+}
+
+func newOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
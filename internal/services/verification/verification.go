@@ -0,0 +1,164 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/lib/mailer"
+	"github.com/AronditFire/User-Service/internal/lib/sms"
+	"github.com/AronditFire/User-Service/internal/lib/tokenhash"
+	"github.com/AronditFire/User-Service/internal/storage"
+	val "github.com/AronditFire/User-Service/internal/validator"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired verification token")
+)
+
+type Verification struct {
+	log          *slog.Logger
+	userProvider UserProvider
+	tokenRepo    TokenRepo
+	mailer       mailer.Mailer
+	phoneRepo    PhoneRepo
+	sms          sms.SMSSender
+	phone        PhoneOptions
+	tokenSecret  string // ключ HMAC, тот же что для refresh токенов
+	tokenTTL     time.Duration
+	linkURL      string // страница фронтенда, токен добавляется параметром token
+}
+
+type UserProvider interface {
+	UserByID(ctx context.Context, userID int64) (models.User, error)
+	UserByEmail(ctx context.Context, email string) (models.User, error)
+}
+
+type TokenRepo interface {
+	SaveEmailVerificationToken(ctx context.Context, tokenHash string, userID int64, email string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int64, error)
+}
+
+func New(
+	log *slog.Logger,
+	userProvider UserProvider,
+	tokenRepo TokenRepo,
+	mailer mailer.Mailer,
+	phoneRepo PhoneRepo,
+	sms sms.SMSSender,
+	phone PhoneOptions,
+	tokenSecret string,
+	tokenTTL time.Duration,
+	linkURL string,
+) *Verification {
+	return &Verification{
+		log:          log,
+		userProvider: userProvider,
+		tokenRepo:    tokenRepo,
+		mailer:       mailer,
+		phoneRepo:    phoneRepo,
+		sms:          sms,
+		phone:        phone,
+		tokenSecret:  tokenSecret,
+		tokenTTL:     tokenTTL,
+		linkURL:      linkURL,
+	}
+}
+
+// SendVerificationEmail sends the link if the address belongs to an unverified user.
+// The result is the same for unknown addresses so the method can not be used to enumerate users.
+func (v *Verification) SendVerificationEmail(ctx context.Context, email string) error {
+	const op = "verification.SendVerificationEmail"
+	log := v.log.With(slog.String("op", op))
+
+	user, err := v.userProvider.UserByEmail(ctx, val.NormalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("verification requested for unknown email")
+			return nil
+		}
+		v.log.Error("failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := v.send(ctx, user); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SendVerificationEmailToUser is called after registration
+func (v *Verification) SendVerificationEmailToUser(ctx context.Context, userID int64) error {
+	const op = "verification.SendVerificationEmailToUser"
+
+	user, err := v.userProvider.UserByID(ctx, userID)
+	if err != nil {
+		v.log.Error("failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := v.send(ctx, user); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the email as verified, each token works once
+func (v *Verification) VerifyEmail(ctx context.Context, token string) error {
+	const op = "verification.VerifyEmail"
+	log := v.log.With(slog.String("op", op))
+
+	userID, err := v.tokenRepo.VerifyEmail(ctx, tokenhash.Hash(token, v.tokenSecret))
+	if err != nil {
+		if errors.Is(err, storage.ErrVerificationTokenNotFound) {
+			log.Warn("invalid email verification token")
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		v.log.Error("failed to verify email", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("email verified", slog.Int64("userID", userID))
+
+	return nil
+}
+
+func (v *Verification) send(ctx context.Context, user models.User) error {
+	log := v.log.With(slog.Int64("userID", user.ID))
+
+	if user.EmailVerifiedAt != nil {
+		log.Info("email is already verified")
+		return nil
+	}
+
+	token := uuid.NewString()
+	if err := v.tokenRepo.SaveEmailVerificationToken(ctx, tokenhash.Hash(token, v.tokenSecret), user.ID, user.Email, time.Now().Add(v.tokenTTL)); err != nil {
+		v.log.Error("failed to save verification token", slog.String("error", err.Error()))
+		return err
+	}
+
+	link, err := url.Parse(v.linkURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	err = v.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес, перейдите по ссылке:\n%s\n\nСсылка действует %s. Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			user.Username, link.String(), v.tokenTTL),
+	})
+	if err != nil {
+		v.log.Error("failed to send verification email", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("verification email sent")
+
+	return nil
+}
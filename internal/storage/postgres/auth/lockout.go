@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// AcquireLoginAttempt counts the attempt in advance in one statement, if the key is not locked and its backoff has passed.
+// ok false means the attempt is throttled, f then holds the current counter.
+func (s *Storage) AcquireLoginAttempt(ctx context.Context, key string, b models.LoginBackoff) (f models.LoginFailures, ok bool, err error) {
+	const op = "storage.repo.AcquireLoginAttempt"
+
+	// задержка считается тут же, чтобы параллельные попытки не проходили одну и ту же проверку
+	err = s.pool.QueryRow(ctx, `
+        INSERT INTO login_failures (key, failures, last_failure_at) VALUES ($1, 1, now())
+        ON CONFLICT (key) DO UPDATE SET
+            failures = CASE WHEN login_failures.last_failure_at < now() - make_interval(secs => $5::float8)
+                THEN 1 ELSE login_failures.failures + 1 END,
+            locked_until = CASE WHEN login_failures.last_failure_at < now() - make_interval(secs => $5::float8)
+                THEN NULL ELSE login_failures.locked_until END,
+            last_failure_at = now()
+        WHERE (login_failures.locked_until IS NULL OR login_failures.locked_until <= now())
+          AND (login_failures.last_failure_at < now() - make_interval(secs => $5::float8)
+               OR login_failures.failures <= $2
+               OR login_failures.last_failure_at + make_interval(secs => least(
+                      $3::float8 * power(2::float8, least(login_failures.failures - $2 - 1, 30)), $4::float8)) <= now())
+        RETURNING failures, last_failure_at, locked_until
+    `, key, b.FreeAttempts, b.BaseDelay.Seconds(), b.MaxDelay.Seconds(), b.ResetAfter.Seconds()).
+		Scan(&f.Failures, &f.LastFailureAt, &f.LockedUntil)
+	if err == nil {
+		return f, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.LoginFailures{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.pool.QueryRow(ctx, `
+        SELECT failures, last_failure_at, locked_until FROM login_failures WHERE key = $1
+    `, key).Scan(&f.Failures, &f.LastFailureAt, &f.LockedUntil)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return models.LoginFailures{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, false, nil
+}
+
+// RefundLoginAttempt takes back an attempt counted by AcquireLoginAttempt that turned out not to be a failure
+func (s *Storage) RefundLoginAttempt(ctx context.Context, key string) error {
+	const op = "storage.repo.RefundLoginAttempt"
+
+	if _, err := s.pool.Exec(ctx, `
+        UPDATE login_failures SET failures = greatest(failures - 1, 0) WHERE key = $1
+    `, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	const op = "storage.repo.LockLogin"
+
+	if _, err := s.pool.Exec(ctx, `UPDATE login_failures SET locked_until = $2 WHERE key = $1`, key, until); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClearLoginFailures removes the counter and the lock, stale counters of other keys are cleaned up on the way
+func (s *Storage) ClearLoginFailures(ctx context.Context, key string, staleAfter time.Duration) error {
+	const op = "storage.repo.ClearLoginFailures"
+
+	if _, err := s.pool.Exec(ctx, `
+        DELETE FROM login_failures
+        WHERE key = $1
+           OR (last_failure_at < now() - make_interval(secs => $2) AND (locked_until IS NULL OR locked_until < now()))
+    `, key, staleAfter.Seconds()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// SaveTOTP stores a new unconfirmed secret with its recovery codes, replacing the previous unconfirmed ones
+func (s *Storage) SaveTOTP(ctx context.Context, userID int64, secret []byte, recoveryCodeHashes []string) error {
+	const op = "storage.repo.SaveTOTP"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO user_totp (user_id, secret_encrypted)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE
+            SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, created_at = now()
+            WHERE user_totp.confirmed_at IS NULL
+    `, userID, secret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrMFAAlreadyEnabled
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) TOTP(ctx context.Context, userID int64) (models.TOTP, error) {
+	const op = "storage.repo.TOTP"
+
+	totp := models.TOTP{UserID: userID}
+	err := s.pool.QueryRow(ctx, `
+        SELECT secret_encrypted, confirmed_at, last_used_step FROM user_totp WHERE user_id = $1
+    `, userID).Scan(&totp.Secret, &totp.ConfirmedAt, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TOTP{}, fmt.Errorf("%s: %w", op, storage.ErrMFANotFound)
+		}
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return totp, nil
+}
+
+// ConfirmedTOTPCount returns how many users have 2FA enabled
+func (s *Storage) ConfirmedTOTPCount(ctx context.Context) (int, error) {
+	const op = "storage.repo.ConfirmedTOTPCount"
+
+	var count int
+	if err := s.pool.QueryRow(ctx, `
+        SELECT count(*) FROM user_totp WHERE confirmed_at IS NOT NULL
+    `).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// ConfirmTOTP enables 2FA, step is the window of the code used for confirmation
+func (s *Storage) ConfirmTOTP(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.repo.ConfirmTOTP"
+
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE user_totp SET confirmed_at = now(), last_used_step = $2
+        WHERE user_id = $1 AND confirmed_at IS NULL
+    `, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFANotFound)
+	}
+
+	return nil
+}
+
+// UseTOTPStep marks the code window as used, a code can be accepted only once
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.repo.UseTOTPStep"
+
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE user_totp SET last_used_step = $2
+        WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+    `, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPStepUsed)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteTOTP(ctx context.Context, userID int64) error {
+	const op = "storage.repo.DeleteTOTP"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrMFANotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// незавершённые входы больше нельзя подтвердить
+	if _, err = tx.Exec(ctx, `DELETE FROM mfa_challenges WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveMFAChallenge stores the second login step and drops expired challenges of the user
+func (s *Storage) SaveMFAChallenge(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error {
+	const op = "storage.repo.SaveMFAChallenge"
+
+	if _, err := s.pool.Exec(ctx, `
+        DELETE FROM mfa_challenges WHERE user_id = $1 AND expires_at <= now()
+    `, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+        INSERT INTO mfa_challenges (token_hash, user_id, expires_at) VALUES ($1, $2, $3)
+    `, tokenHash, userID, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MFAChallengeAttempt counts an attempt to pass the challenge and returns it, expired challenges are not found
+func (s *Storage) MFAChallengeAttempt(ctx context.Context, tokenHash string) (models.MFAChallenge, error) {
+	const op = "storage.repo.MFAChallengeAttempt"
+
+	var challenge models.MFAChallenge
+	err := s.pool.QueryRow(ctx, `
+        UPDATE mfa_challenges SET attempts = attempts + 1
+        WHERE token_hash = $1 AND expires_at > now()
+        RETURNING user_id, attempts, expires_at
+    `, tokenHash).Scan(&challenge.UserID, &challenge.Attempts, &challenge.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeMissing)
+		}
+		return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challenge, nil
+}
+
+func (s *Storage) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	const op = "storage.repo.DeleteMFAChallenge"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM mfa_challenges WHERE token_hash = $1`, tokenHash); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates all previous recovery codes of the user
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	const op = "storage.repo.ReplaceRecoveryCodes"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecoveryCodes returns unused recovery codes of the user
+func (s *Storage) RecoveryCodes(ctx context.Context, userID int64) ([]models.RecoveryCode, error) {
+	const op = "storage.repo.RecoveryCodes"
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT id, code_hash FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var codes []models.RecoveryCode
+	for rows.Next() {
+		var code models.RecoveryCode
+		if err := rows.Scan(&code.ID, &code.Hash); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		codes = append(codes, code)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode marks the code as used, each code is accepted only once
+func (s *Storage) UseRecoveryCode(ctx context.Context, codeID int64) error {
+	const op = "storage.repo.UseRecoveryCode"
+
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE mfa_recovery_codes SET used_at = now() WHERE id = $1 AND used_at IS NULL
+    `, codeID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeUsed)
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `
+        INSERT INTO mfa_recovery_codes (user_id, code_hash)
+        SELECT $1, unnest($2::text[])
+    `, userID, codeHashes)
+	return err
+}
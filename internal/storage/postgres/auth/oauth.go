@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) SaveOAuthClient(ctx context.Context, client models.OAuthClient) error {
+	const op = "storage.repo.SaveOAuthClient"
+
+	if _, err := s.pool.Exec(ctx, `
+        INSERT INTO oauth_clients (id, name, redirect_uris, scopes, grant_types, secret_hash)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+    `, client.ID, client.Name, client.RedirectURIs, client.Scopes, client.GrantTypes, client.SecretHash); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) OAuthClient(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	const op = "storage.repo.OAuthClient"
+
+	var client models.OAuthClient
+	err := s.pool.QueryRow(ctx, `
+        SELECT id, name, redirect_uris, scopes, grant_types, COALESCE(secret_hash, ''), created_at
+        FROM oauth_clients WHERE id = $1
+    `, clientID).Scan(&client.ID, &client.Name, &client.RedirectURIs, &client.Scopes, &client.GrantTypes,
+		&client.SecretHash, &client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.OAuthClient{}, fmt.Errorf("%s: %w", op, storage.ErrOAuthClientNotFound)
+		}
+		return models.OAuthClient{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return client, nil
+}
+
+// SaveClientAccessToken remembers a client_credentials access token, so it can be revoked with the client
+func (s *Storage) SaveClientAccessToken(ctx context.Context, clientID string, access models.AccessToken) error {
+	const op = "storage.repo.SaveClientAccessToken"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM oauth_client_access_tokens WHERE expires_at <= now()`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+        INSERT INTO oauth_client_access_tokens (jti, client_id, expires_at) VALUES ($1, $2, $3)
+    `, access.ID, clientID, access.ExpiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteOAuthClient deletes the client together with its pending requests, codes and tokens
+func (s *Storage) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	const op = "storage.repo.DeleteOAuthClient"
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM oauth_clients WHERE id = $1`, clientID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrOAuthClientNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveAuthorizationRequest(ctx context.Context, idHash string, req models.AuthorizationRequest) error {
+	const op = "storage.repo.SaveAuthorizationRequest"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM oauth_authorization_requests WHERE expires_at <= now()`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+        INSERT INTO oauth_authorization_requests (id_hash, client_id, redirect_uri, scopes, state, code_challenge, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, idHash, req.ClientID, req.RedirectURI, req.Scopes, req.State, req.CodeChallenge, req.ExpiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AuthorizationRequest returns an unexpired request without using it, the consent page shows it to the user
+func (s *Storage) AuthorizationRequest(ctx context.Context, idHash string) (models.AuthorizationRequest, error) {
+	const op = "storage.repo.AuthorizationRequest"
+
+	req, err := scanAuthorizationRequest(s.pool.QueryRow(ctx, `
+        SELECT client_id, redirect_uri, scopes, state, code_challenge, expires_at
+        FROM oauth_authorization_requests WHERE id_hash = $1 AND expires_at > now()
+    `, idHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AuthorizationRequest{}, fmt.Errorf("%s: %w", op, storage.ErrAuthorizationRequestNotFound)
+		}
+		return models.AuthorizationRequest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return req, nil
+}
+
+// TakeAuthorizationRequest deletes the request and returns it, the user answers each request once
+func (s *Storage) TakeAuthorizationRequest(ctx context.Context, idHash string) (models.AuthorizationRequest, error) {
+	const op = "storage.repo.TakeAuthorizationRequest"
+
+	req, err := scanAuthorizationRequest(s.pool.QueryRow(ctx, `
+        DELETE FROM oauth_authorization_requests WHERE id_hash = $1 AND expires_at > now()
+        RETURNING client_id, redirect_uri, scopes, state, code_challenge, expires_at
+    `, idHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AuthorizationRequest{}, fmt.Errorf("%s: %w", op, storage.ErrAuthorizationRequestNotFound)
+		}
+		return models.AuthorizationRequest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return req, nil
+}
+
+func (s *Storage) SaveAuthorizationCode(ctx context.Context, codeHash string, code models.AuthorizationCode) error {
+	const op = "storage.repo.SaveAuthorizationCode"
+
+	// использованные коды хранятся до истечения, чтобы распознать повторное предъявление
+	if _, err := s.pool.Exec(ctx, `DELETE FROM oauth_authorization_codes WHERE expires_at <= now()`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+        INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, codeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scopes, code.CodeChallenge, code.ExpiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AuthorizationCode returns the code whether it was used or not, storage.ErrAuthorizationCodeNotFound if there is no such code
+func (s *Storage) AuthorizationCode(ctx context.Context, codeHash string) (models.AuthorizationCode, error) {
+	const op = "storage.repo.AuthorizationCode"
+
+	code, err := scanAuthorizationCode(s.pool.QueryRow(ctx, `
+        SELECT client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, COALESCE(family_id::text, '')
+        FROM oauth_authorization_codes WHERE code_hash = $1
+    `, codeHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, storage.ErrAuthorizationCodeNotFound)
+		}
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
+
+// UseAuthorizationCode marks the code used and links it to the session familyID started by the exchange.
+// The code is consumed only if it is unused, not expired and was issued for the checked client, redirect uri and challenge;
+// otherwise it is returned with storage.ErrAuthorizationCodeUsed (FamilyID is the session to revoke) or
+// storage.ErrAuthorizationCodeNotFound.
+func (s *Storage) UseAuthorizationCode(ctx context.Context, codeHash string, checked models.AuthorizationCode, familyID string) (models.AuthorizationCode, error) {
+	const op = "storage.repo.UseAuthorizationCode"
+
+	code, err := scanAuthorizationCode(s.pool.QueryRow(ctx, `
+        UPDATE oauth_authorization_codes SET used_at = now(), family_id = $2
+        WHERE code_hash = $1 AND used_at IS NULL AND expires_at > now()
+          AND client_id = $3 AND redirect_uri = $4 AND code_challenge = $5
+        RETURNING client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, COALESCE(family_id::text, '')
+    `, codeHash, familyID, checked.ClientID, checked.RedirectURI, checked.CodeChallenge))
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// код успели использовать параллельно, или он истек между проверкой и обменом
+	code, err = s.AuthorizationCode(ctx, codeHash)
+	if err != nil {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if code.UsedAt != nil {
+		return code, fmt.Errorf("%s: %w", op, storage.ErrAuthorizationCodeUsed)
+	}
+
+	return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, storage.ErrAuthorizationCodeNotFound)
+}
+
+func scanAuthorizationRequest(row pgx.Row) (models.AuthorizationRequest, error) {
+	var req models.AuthorizationRequest
+	err := row.Scan(&req.ClientID, &req.RedirectURI, &req.Scopes, &req.State, &req.CodeChallenge, &req.ExpiresAt)
+	return req, err
+}
+
+func scanAuthorizationCode(row pgx.Row) (models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	err := row.Scan(&code.ClientID, &code.UserID, &code.RedirectURI, &code.Scopes, &code.CodeChallenge,
+		&code.ExpiresAt, &code.UsedAt, &code.FamilyID)
+	return code, err
+}
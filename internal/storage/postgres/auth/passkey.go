@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+func (s *Storage) SavePasskey(ctx context.Context, passkey models.Passkey) error {
+	const op = "storage.repo.SavePasskey"
+
+	_, err := s.pool.Exec(ctx, `
+        INSERT INTO webauthn_credentials (id, user_id, name, public_key, sign_count, aaguid, attestation_format)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, passkey.ID, passkey.UserID, passkey.Name, passkey.PublicKey, int64(passkey.SignCount), passkey.AAGUID, passkey.Format)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return fmt.Errorf("%s: %w", op, storage.ErrPasskeyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Passkey(ctx context.Context, credentialID []byte) (models.Passkey, error) {
+	const op = "storage.repo.Passkey"
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT id, user_id, name, public_key, sign_count, aaguid, attestation_format, created_at, last_used_at
+        FROM webauthn_credentials WHERE id = $1
+    `, credentialID)
+	if err != nil {
+		return models.Passkey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passkey, err := pgx.CollectOneRow(rows, scanPasskey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Passkey{}, fmt.Errorf("%s: %w", op, storage.ErrPasskeyNotFound)
+		}
+		return models.Passkey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return passkey, nil
+}
+
+func (s *Storage) UserPasskeys(ctx context.Context, userID int64) ([]models.Passkey, error) {
+	const op = "storage.repo.UserPasskeys"
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT id, user_id, name, public_key, sign_count, aaguid, attestation_format, created_at, last_used_at
+        FROM webauthn_credentials WHERE user_id = $1
+        ORDER BY created_at
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passkeys, err := pgx.CollectRows(rows, scanPasskey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return passkeys, nil
+}
+
+// UpdatePasskeyUsage saves the sign counter reported by the authenticator. The counter only grows, so of two
+// parallel assertions with the same counter one gets storage.ErrPasskeySignCount; zero stays allowed for
+// authenticators without a counter.
+func (s *Storage) UpdatePasskeyUsage(ctx context.Context, credentialID []byte, signCount uint32) error {
+	const op = "storage.repo.UpdatePasskeyUsage"
+
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE webauthn_credentials SET sign_count = $2, last_used_at = now()
+        WHERE id = $1 AND (sign_count < $2 OR ($2 = 0 AND sign_count = 0))
+    `, credentialID, int64(signCount))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM webauthn_credentials WHERE id = $1)`, credentialID).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrPasskeyNotFound)
+	}
+	return fmt.Errorf("%s: %w", op, storage.ErrPasskeySignCount)
+}
+
+// SaveWebAuthnChallenge stores the ceremony challenge, userID 0 means login without a known user
+func (s *Storage) SaveWebAuthnChallenge(ctx context.Context, challenge []byte, kind string, userID int64, expiresAt time.Time) error {
+	const op = "storage.repo.SaveWebAuthnChallenge"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM webauthn_challenges WHERE expires_at <= now()`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+        INSERT INTO webauthn_challenges (challenge, kind, user_id, expires_at)
+        VALUES ($1, $2, NULLIF($3, 0), $4)
+    `, challenge, kind, userID, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeWebAuthnChallenge deletes the challenge and returns the user it was issued for, each challenge is used once
+func (s *Storage) TakeWebAuthnChallenge(ctx context.Context, challenge []byte, kind string) (int64, error) {
+	const op = "storage.repo.TakeWebAuthnChallenge"
+
+	var userID int64
+	err := s.pool.QueryRow(ctx, `
+        DELETE FROM webauthn_challenges
+        WHERE challenge = $1 AND kind = $2 AND expires_at > now()
+        RETURNING COALESCE(user_id, 0)
+    `, challenge, kind).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrWebAuthnChallengeMissing)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func scanPasskey(row pgx.CollectableRow) (models.Passkey, error) {
+	var passkey models.Passkey
+	var signCount int64
+	err := row.Scan(&passkey.ID, &passkey.UserID, &passkey.Name, &passkey.PublicKey, &signCount,
+		&passkey.AAGUID, &passkey.Format, &passkey.CreatedAt, &passkey.LastUsedAt)
+	passkey.SignCount = uint32(signCount)
+	return passkey, err
+}
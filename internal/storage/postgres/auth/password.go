@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// SavePasswordResetToken stores a new token, previous unused tokens of the user stop working
+func (s *Storage) SavePasswordResetToken(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error {
+	const op = "storage.repo.SavePasswordResetToken"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `
+        DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL
+    `, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec(ctx, `
+        INSERT INTO password_reset_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)
+    `, tokenHash, userID, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PasswordResetUserID returns the owner of a valid unused token without using it
+func (s *Storage) PasswordResetUserID(ctx context.Context, tokenHash string) (int64, error) {
+	const op = "storage.repo.PasswordResetUserID"
+
+	var userID int64
+	err := s.pool.QueryRow(ctx, `
+        SELECT user_id FROM password_reset_tokens
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+    `, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrResetTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// ResetPassword uses the token, saves the new password hash and revokes all refresh tokens of the user
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash string, passHash string) (int64, error) {
+	const op = "storage.repo.ResetPassword"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	var userID int64
+	err = tx.QueryRow(ctx, `
+        UPDATE password_reset_tokens SET used_at = now()
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+        RETURNING user_id
+    `, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrResetTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1`, userID, passHash); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec(ctx, `
+        UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL
+    `, userID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec(ctx, `
+        DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL
+    `, userID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// ChangePassword saves the new password hash and revokes refresh tokens of all sessions except keepSessionID
+// in one transaction; returns ids of the revoked sessions
+func (s *Storage) ChangePassword(ctx context.Context, userID int64, passHash, keepSessionID string) ([]string, error) {
+	const op = "storage.repo.ChangePassword"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1`, userID, passHash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrUserNotFound
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.Query(ctx, `
+        UPDATE refresh_tokens SET revoked_at = now()
+        WHERE user_id = $1 AND revoked_at IS NULL AND family_id::text <> $2
+        RETURNING family_id::text
+    `, userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	seen := make(map[string]struct{})
+	var sessionIDs []string
+	for rows.Next() {
+		var sessionID string
+		if err = rows.Scan(&sessionID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if _, ok := seen[sessionID]; !ok {
+			seen[sessionID] = struct{}{}
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessionIDs, nil
+}
+
+// RehashPassword replaces the hash only if it is still oldHash; false if the password was changed meanwhile
+func (s *Storage) RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) (bool, error) {
+	const op = "storage.repo.RehashPassword"
+
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2
+    `, userID, oldHash, newHash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
@@ -9,11 +9,14 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/lib/pq"
 	"time"
 )
 
+const uniqueViolationCode = "23505"
+
 type Storage struct {
 	pool *pgxpool.Pool
 }
@@ -33,9 +36,10 @@ func (s *Storage) Close() {
 	s.pool.Close()
 }
 
-// SaveUser saves user to db
+// SaveUser saves user to db together with the role in one transaction.
+// Unique violations are returned as storage.ErrUsernameTaken, ErrEmailTaken or ErrPhoneTaken.
 func (s *Storage) SaveUser(ctx context.Context, username string,
-	email string, FIO string, phoneNumber string, passHash string,
+	email string, FIO string, phoneNumber string, passHash string, role string,
 ) (int64, error) {
 	const op = "storage.repo.SaveUser"
 
@@ -57,17 +61,48 @@ func (s *Storage) SaveUser(ctx context.Context, username string,
 	err = tx.QueryRow(ctx,
 		"INSERT INTO users (username, email, fio, phone_number, password_hash) VALUES ($1, $2, $3, $4, $5) RETURNING id",
 		username, email, FIO, phoneNumber, passHash).Scan(&userID)
+	if err != nil {
+		err = uniqueViolation(err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO user_roles (user_id, role_id)
+        SELECT $1, r.id FROM roles r WHERE r.name = $2
+    `, userID, role)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrRoleNotFound
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
-	if err := tx.Commit(ctx); err != nil {
+	if err = tx.Commit(ctx); err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return userID, nil
 }
 
+// uniqueViolation maps unique constraint violations on users to storage errors
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return err
+	}
+
+	switch pgErr.ConstraintName {
+	case "users_username_key":
+		return storage.ErrUsernameTaken
+	case "users_email_key", "users_email_lower_key":
+		return storage.ErrEmailTaken
+	case "users_phone_number_key":
+		return storage.ErrPhoneTaken
+	}
+	return storage.ErrUserExists
+}
+
 func (s *Storage) User(ctx context.Context, username string) (models.User, error) {
 	const op = "storage.repo.User"
 
@@ -92,8 +127,8 @@ func (s *Storage) User(ctx context.Context, username string) (models.User, error
 
 	var user models.User
 	for i := 0; i < 3; i++ {
-		err = tx.QueryRow(ctx, "SELECT id, username, email, FIO, phone_number, password_hash FROM users WHERE username = $1", username).
-			Scan(&user.ID, &user.Username, &user.Email, &user.FIO, &user.PhoneNumber, &user.PassHash)
+		err = tx.QueryRow(ctx, "SELECT id, username, email, FIO, phone_number, password_hash, blocked_at, email_verified_at, phone_verified_at FROM users WHERE username = $1", username).
+			Scan(&user.ID, &user.Username, &user.Email, &user.FIO, &user.PhoneNumber, &user.PassHash, &user.BlockedAt, &user.EmailVerifiedAt, &user.PhoneVerifiedAt)
 		if err == nil {
 			return user, nil
 		}
@@ -109,6 +144,40 @@ func (s *Storage) User(ctx context.Context, username string) (models.User, error
 	return models.User{}, fmt.Errorf("%s: %w", op, errors.New("could not serialize read transaction"))
 }
 
+func (s *Storage) UserByID(ctx context.Context, userID int64) (models.User, error) {
+	const op = "storage.repo.UserByID"
+
+	return s.userBy(ctx, op, "id = $1", userID)
+}
+
+// UserByEmail looks the user up by email case-insensitively
+func (s *Storage) UserByEmail(ctx context.Context, email string) (models.User, error) {
+	const op = "storage.repo.UserByEmail"
+
+	return s.userBy(ctx, op, "lower(email) = lower($1)", email)
+}
+
+func (s *Storage) UserByPhone(ctx context.Context, phoneNumber string) (models.User, error) {
+	const op = "storage.repo.UserByPhone"
+
+	return s.userBy(ctx, op, "phone_number = $1", phoneNumber)
+}
+
+func (s *Storage) userBy(ctx context.Context, op string, where string, arg any) (models.User, error) {
+	var user models.User
+	err := s.pool.QueryRow(ctx,
+		"SELECT id, username, email, FIO, phone_number, password_hash, blocked_at, email_verified_at, phone_verified_at FROM users WHERE "+where, arg).
+		Scan(&user.ID, &user.Username, &user.Email, &user.FIO, &user.PhoneNumber, &user.PassHash, &user.BlockedAt, &user.EmailVerifiedAt, &user.PhoneVerifiedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
 func (s *Storage) SetRole(ctx context.Context, userID int64, role string) error {
 	const op = "storage.repo.SetRole"
 	_, err := s.pool.Exec(ctx, `
@@ -168,20 +237,25 @@ func (s *Storage) Role(ctx context.Context, userID int64) (string, error) {
 	return "", fmt.Errorf("%s: %w", op, errors.New("could not serialize read transaction"))
 }
 
-func (s *Storage) SaveToken(ctx context.Context, refreshToken string, userID int64, expiresAt time.Time) error {
+// SaveToken stores refresh token together with the access token issued alongside it
+func (s *Storage) SaveToken(ctx context.Context, tokenHash string, userID int64, familyID string,
+	info models.SessionInfo, access models.AccessToken, grant models.OAuthGrant, expiresAt time.Time,
+) error {
 	const op = "storage.repo.SaveToken"
 
 	_, err := s.pool.Exec(ctx, `
-        INSERT INTO refresh_tokens (token, user_id, expires_at)
-        VALUES ($1, $2, $3)
-    `, refreshToken, userID, expiresAt)
+        INSERT INTO refresh_tokens (token_hash, user_id, family_id, device_name, user_agent, ip, access_jti, access_expires_at,
+                                    client_id, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11)
+    `, tokenHash, userID, familyID, info.DeviceName, info.UserAgent, info.IP, access.ID, access.ExpiresAt,
+		grant.ClientID, grant.Scopes, expiresAt)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
-func (s *Storage) GetToken(ctx context.Context, refreshToken string) (models.RefreshTokenClaims, error) {
+func (s *Storage) GetToken(ctx context.Context, tokenHash string) (models.RefreshTokenClaims, error) {
 	const op = "storage.repo.GetToken"
 
 	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
@@ -206,14 +280,16 @@ func (s *Storage) GetToken(ctx context.Context, refreshToken string) (models.Ref
 	var rt models.RefreshTokenClaims
 	for i := 0; i < 3; i++ {
 		err = tx.QueryRow(ctx, `
-            SELECT token, user_id, issued_at, expires_at
-            FROM refresh_tokens WHERE token = $1
-        `, refreshToken).Scan(&rt.Token, &rt.UserID, &rt.IssuedAt, &rt.ExpiresAt)
+            SELECT token_hash, user_id, family_id::text, issued_at, expires_at, used_at, revoked_at,
+                   COALESCE(client_id, ''), COALESCE(scopes, '{}')
+            FROM refresh_tokens WHERE token_hash = $1
+        `, tokenHash).Scan(&rt.TokenHash, &rt.UserID, &rt.FamilyID, &rt.IssuedAt, &rt.ExpiresAt, &rt.UsedAt, &rt.RevokedAt,
+			&rt.Grant.ClientID, &rt.Grant.Scopes)
 		if err == nil {
 			return rt, nil
 		}
 		if errors.Is(err, pgx.ErrNoRows) {
-			return models.RefreshTokenClaims{}, fmt.Errorf("%s: %w", op, pgx.ErrNoRows)
+			return models.RefreshTokenClaims{}, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
 		}
 		_, rbErr := tx.Exec(ctx, fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", sp))
 		if rbErr != nil {
@@ -225,10 +301,72 @@ func (s *Storage) GetToken(ctx context.Context, refreshToken string) (models.Ref
 	return models.RefreshTokenClaims{}, fmt.Errorf("%s: %w", op, errors.New("could not serialize read transaction"))
 }
 
-func (s *Storage) DeleteToken(ctx context.Context, refreshToken string) error {
-	const op = "storage.repo.DeleteToken"
+// RotateToken marks the old refresh token as used and stores its successor in the same family.
+// Device name and session start are carried over, user agent and IP are taken from the current client.
+// Returns storage.ErrTokenReused if the old token was already used or revoked.
+func (s *Storage) RotateToken(ctx context.Context, oldTokenHash string, newTokenHash string,
+	info models.SessionInfo, access models.AccessToken, expiresAt time.Time,
+) error {
+	const op = "storage.repo.RotateToken"
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	var (
+		userID     int64
+		familyID   string
+		deviceName string
+		startedAt  time.Time
+		clientID   *string
+		scopes     []string
+	)
+	err = tx.QueryRow(ctx, `
+        UPDATE refresh_tokens SET used_at = now()
+        WHERE token_hash = $1 AND used_at IS NULL AND revoked_at IS NULL
+        RETURNING user_id, family_id::text, device_name, session_started_at, client_id, scopes
+    `, oldTokenHash).Scan(&userID, &familyID, &deviceName, &startedAt, &clientID, &scopes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrTokenReused)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO refresh_tokens (token_hash, user_id, family_id, device_name, user_agent, ip, session_started_at,
+                                    access_jti, access_expires_at, client_id, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `, newTokenHash, userID, familyID, deviceName, info.UserAgent, info.IP, startedAt, access.ID, access.ExpiresAt,
+		clientID, scopes, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeTokenFamily revokes every refresh token issued within the family
+func (s *Storage) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	const op = "storage.repo.RevokeTokenFamily"
 
-	_, err := s.pool.Exec(ctx, `DELETE FROM refresh_tokens WHERE token = $1`, refreshToken)
+	_, err := s.pool.Exec(ctx, `
+        UPDATE refresh_tokens SET revoked_at = now()
+        WHERE family_id = $1 AND revoked_at IS NULL
+    `, familyID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
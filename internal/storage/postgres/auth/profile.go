@@ -149,3 +149,49 @@ func (s *Storage) ChangeRole(ctx context.Context, userID int64, role string) err
 
 	return nil
 }
+
+// SetBlocked blocks or unblocks the user, blocking also revokes all refresh tokens of the user
+func (s *Storage) SetBlocked(ctx context.Context, userID int64, blocked bool) error {
+	const op = "storage.repo.SetBlocked"
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE users SET blocked_at = CASE WHEN $2 THEN now() ELSE NULL END
+        WHERE id = $1
+    `, userID, blocked)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrUserNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if blocked {
+		_, err = tx.Exec(ctx, `
+            UPDATE refresh_tokens SET revoked_at = now()
+            WHERE user_id = $1 AND revoked_at IS NULL
+        `, userID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TakeRateLimitToken refills the bucket for the time passed since the last request and takes one token if there is one.
+// All SET expressions see the old row, so the refill is computed once and the update is atomic.
+func (s *Storage) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (bool, float64, error) {
+	const op = "storage.repo.TakeRateLimitToken"
+
+	var allowed bool
+	var tokens float64
+	err := s.pool.QueryRow(ctx, `
+        INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at) VALUES ($1, $3::float8 - 1, true, now())
+        ON CONFLICT (key) DO UPDATE SET
+            allowed = LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2::float8) >= 1,
+            tokens = LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2::float8)
+                - CASE WHEN LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2::float8) >= 1
+                    THEN 1 ELSE 0 END,
+            updated_at = now()
+        RETURNING allowed, tokens
+    `, key, rate, float64(burst)).Scan(&allowed, &tokens)
+	if err != nil {
+		return false, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, tokens, nil
+}
+
+func (s *Storage) DeleteIdleRateLimitBuckets(ctx context.Context, idleFor time.Duration) error {
+	const op = "storage.repo.DeleteIdleRateLimitBuckets"
+
+	if _, err := s.pool.Exec(ctx, `
+        DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)
+    `, idleFor.Seconds()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// RevokeAccessToken adds a single access token to the denylist
+func (s *Storage) RevokeAccessToken(ctx context.Context, token models.AccessToken) ([]models.RevokedAccessToken, error) {
+	const op = "storage.repo.RevokeAccessToken"
+
+	rows, err := s.pool.Query(ctx, `
+        INSERT INTO revoked_access_tokens (jti, expires_at)
+        VALUES ($1, $2)
+        ON CONFLICT (jti) DO NOTHING
+        RETURNING jti, expires_at, revoked_at
+    `, token.ID, token.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := collectRevoked(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revoked, nil
+}
+
+// RevokeFamilyAccessTokens adds every unexpired access token issued within the token family to the denylist
+func (s *Storage) RevokeFamilyAccessTokens(ctx context.Context, familyID string) ([]models.RevokedAccessToken, error) {
+	const op = "storage.repo.RevokeFamilyAccessTokens"
+
+	rows, err := s.pool.Query(ctx, `
+        INSERT INTO revoked_access_tokens (jti, expires_at)
+        SELECT access_jti, access_expires_at FROM refresh_tokens
+        WHERE family_id = $1::uuid AND access_jti IS NOT NULL AND access_expires_at > now()
+        ON CONFLICT (jti) DO NOTHING
+        RETURNING jti, expires_at, revoked_at
+    `, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := collectRevoked(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revoked, nil
+}
+
+// RevokeUserAccessTokens adds every unexpired access token of the user to the denylist
+func (s *Storage) RevokeUserAccessTokens(ctx context.Context, userID int64) ([]models.RevokedAccessToken, error) {
+	const op = "storage.repo.RevokeUserAccessTokens"
+
+	rows, err := s.pool.Query(ctx, `
+        INSERT INTO revoked_access_tokens (jti, expires_at)
+        SELECT access_jti, access_expires_at FROM refresh_tokens
+        WHERE user_id = $1 AND access_jti IS NOT NULL AND access_expires_at > now()
+        ON CONFLICT (jti) DO NOTHING
+        RETURNING jti, expires_at, revoked_at
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := collectRevoked(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revoked, nil
+}
+
+// RevokeClientAccessTokens adds every unexpired access token issued to the OAuth client to the denylist:
+// tokens of user sessions and client_credentials tokens
+func (s *Storage) RevokeClientAccessTokens(ctx context.Context, clientID string) ([]models.RevokedAccessToken, error) {
+	const op = "storage.repo.RevokeClientAccessTokens"
+
+	rows, err := s.pool.Query(ctx, `
+        INSERT INTO revoked_access_tokens (jti, expires_at)
+        SELECT access_jti, access_expires_at FROM refresh_tokens
+        WHERE client_id = $1 AND access_jti IS NOT NULL AND access_expires_at > now()
+        UNION ALL
+        SELECT jti, expires_at FROM oauth_client_access_tokens
+        WHERE client_id = $1 AND expires_at > now()
+        ON CONFLICT (jti) DO NOTHING
+        RETURNING jti, expires_at, revoked_at
+    `, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := collectRevoked(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revoked, nil
+}
+
+// RevokedAccessTokens returns unexpired denylist entries revoked after since
+func (s *Storage) RevokedAccessTokens(ctx context.Context, since time.Time) ([]models.RevokedAccessToken, error) {
+	const op = "storage.repo.RevokedAccessTokens"
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT jti, expires_at, revoked_at FROM revoked_access_tokens
+        WHERE revoked_at > $1 AND expires_at > now()
+        ORDER BY revoked_at
+    `, since)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := collectRevoked(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revoked, nil
+}
+
+// PurgeRevokedAccessTokens deletes denylist entries of already expired tokens
+func (s *Storage) PurgeRevokedAccessTokens(ctx context.Context) error {
+	const op = "storage.repo.PurgeRevokedAccessTokens"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at <= now()`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func collectRevoked(rows pgx.Rows) ([]models.RevokedAccessToken, error) {
+	defer rows.Close()
+
+	var revoked []models.RevokedAccessToken
+	for rows.Next() {
+		var token models.RevokedAccessToken
+		if err := rows.Scan(&token.ID, &token.ExpiresAt, &token.RevokedAt); err != nil {
+			return nil, err
+		}
+		revoked = append(revoked, token)
+	}
+
+	return revoked, rows.Err()
+}
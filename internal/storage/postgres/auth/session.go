@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+)
+
+// ListSessions returns active sessions of the user, the latest used first
+func (s *Storage) ListSessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "storage.repo.ListSessions"
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT family_id::text, user_id, device_name, user_agent, ip, session_started_at, issued_at, expires_at
+        FROM refresh_tokens
+        WHERE user_id = $1 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+        ORDER BY issued_at DESC
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.Info.DeviceName, &session.Info.UserAgent,
+			&session.Info.IP, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes the session only if it belongs to the user
+func (s *Storage) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	const op = "storage.repo.RevokeSession"
+
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE refresh_tokens SET revoked_at = now()
+        WHERE family_id = $1::uuid AND user_id = $2 AND revoked_at IS NULL
+    `, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions revokes every session of the user except exceptSessionID (may be empty)
+func (s *Storage) RevokeAllSessions(ctx context.Context, userID int64, exceptSessionID string) error {
+	const op = "storage.repo.RevokeAllSessions"
+
+	_, err := s.pool.Exec(ctx, `
+        UPDATE refresh_tokens SET revoked_at = now()
+        WHERE user_id = $1 AND revoked_at IS NULL AND family_id::text <> $2
+    `, userID, exceptSessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
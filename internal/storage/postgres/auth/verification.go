@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AronditFire/User-Service/internal/domain/models"
+	"github.com/AronditFire/User-Service/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// SaveEmailVerificationToken stores a new token, previous unused tokens of the user stop working
+func (s *Storage) SaveEmailVerificationToken(ctx context.Context, tokenHash string, userID int64, email string, expiresAt time.Time) error {
+	const op = "storage.repo.SaveEmailVerificationToken"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `
+        DELETE FROM email_verification_tokens WHERE user_id = $1 AND used_at IS NULL
+    `, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec(ctx, `
+        INSERT INTO email_verification_tokens (token_hash, user_id, email, expires_at) VALUES ($1, $2, $3, $4)
+    `, tokenHash, userID, email, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// VerifyEmail uses the token and marks the address it was sent to as verified.
+// The token does not work if the user's email has changed since it was sent.
+func (s *Storage) VerifyEmail(ctx context.Context, tokenHash string) (int64, error) {
+	const op = "storage.repo.VerifyEmail"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	var userID int64
+	var email string
+	err = tx.QueryRow(ctx, `
+        UPDATE email_verification_tokens SET used_at = now()
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+        RETURNING user_id, email
+    `, tokenHash).Scan(&userID, &email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrVerificationTokenNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE users SET email_verified_at = COALESCE(email_verified_at, now())
+        WHERE id = $1 AND lower(email) = lower($2)
+    `, userID, email)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrVerificationTokenNotFound
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// SavePhoneOTP replaces the pending code of the user, unless the previous one was sent less than resendInterval ago
+func (s *Storage) SavePhoneOTP(ctx context.Context, otp models.PhoneOTP, resendInterval time.Duration) error {
+	const op = "storage.repo.SavePhoneOTP"
+
+	tag, err := s.pool.Exec(ctx, `
+        INSERT INTO phone_otps (user_id, phone_number, code_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id) DO UPDATE
+            SET phone_number = EXCLUDED.phone_number, code_hash = EXCLUDED.code_hash,
+                attempts = 0, expires_at = EXCLUDED.expires_at, created_at = now()
+            WHERE phone_otps.created_at <= now() - make_interval(secs => $5)
+    `, otp.UserID, otp.PhoneNumber, otp.CodeHash, otp.ExpiresAt, resendInterval.Seconds())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrOTPTooFrequent)
+	}
+
+	return nil
+}
+
+// PhoneOTPAttempt counts an attempt to enter the code and returns it, expired codes are not found
+func (s *Storage) PhoneOTPAttempt(ctx context.Context, userID int64) (models.PhoneOTP, error) {
+	const op = "storage.repo.PhoneOTPAttempt"
+
+	otp := models.PhoneOTP{UserID: userID}
+	err := s.pool.QueryRow(ctx, `
+        UPDATE phone_otps SET attempts = attempts + 1
+        WHERE user_id = $1 AND expires_at > now()
+        RETURNING phone_number, code_hash, attempts, expires_at
+    `, userID).Scan(&otp.PhoneNumber, &otp.CodeHash, &otp.Attempts, &otp.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PhoneOTP{}, fmt.Errorf("%s: %w", op, storage.ErrOTPNotFound)
+		}
+		return models.PhoneOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return otp, nil
+}
+
+func (s *Storage) DeletePhoneOTP(ctx context.Context, userID int64) error {
+	const op = "storage.repo.DeletePhoneOTP"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM phone_otps WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// VerifyPhone marks the number as verified and drops the used code.
+// Nothing is verified if the user's number has changed since the code was sent.
+func (s *Storage) VerifyPhone(ctx context.Context, userID int64, phoneNumber string) error {
+	const op = "storage.repo.VerifyPhone"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%s: rollback failed: %v; original error: %w", op, rollbackErr, err)
+			}
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, `DELETE FROM phone_otps WHERE user_id = $1 AND phone_number = $2`, userID, phoneNumber)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrOTPNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err = tx.Exec(ctx, `
+        UPDATE users SET phone_verified_at = COALESCE(phone_verified_at, now())
+        WHERE id = $1 AND phone_number = $2
+    `, userID, phoneNumber)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = storage.ErrOTPNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
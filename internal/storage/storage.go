@@ -1,10 +1,41 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
-	ErrAppNotFound  = errors.New("app not found")
-	ErrRoleNotFound = errors.New("role not found")
+	ErrUserExists    = errors.New("user already exists")
+	ErrUsernameTaken = fmt.Errorf("%w: username is taken", ErrUserExists)
+	ErrEmailTaken    = fmt.Errorf("%w: email is taken", ErrUserExists)
+	ErrPhoneTaken    = fmt.Errorf("%w: phone number is taken", ErrUserExists)
+	ErrUserNotFound  = errors.New("user not found")
+	ErrAppNotFound   = errors.New("app not found")
+	ErrRoleNotFound  = errors.New("role not found")
+	ErrTokenNotFound = errors.New("refresh token not found")
+	ErrTokenReused   = errors.New("refresh token already used")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrMFANotFound         = errors.New("mfa is not configured")
+	ErrMFAAlreadyEnabled   = errors.New("mfa is already enabled")
+	ErrTOTPStepUsed        = errors.New("totp code already used")
+	ErrMFAChallengeMissing = errors.New("mfa challenge not found")
+	ErrRecoveryCodeUsed    = errors.New("recovery code already used")
+
+	ErrPasskeyExists            = errors.New("passkey already registered")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeySignCount         = errors.New("passkey sign count did not increase")
+	ErrWebAuthnChallengeMissing = errors.New("webauthn challenge not found")
+
+	ErrVerificationTokenNotFound = errors.New("verification token not found")
+	ErrOTPNotFound               = errors.New("otp not found")
+	ErrOTPTooFrequent            = errors.New("otp was requested too recently")
+	ErrResetTokenNotFound        = errors.New("password reset token not found")
+
+	ErrOAuthClientNotFound          = errors.New("oauth client not found")
+	ErrAuthorizationRequestNotFound = errors.New("authorization request not found")
+	ErrAuthorizationCodeNotFound    = errors.New("authorization code not found")
+	ErrAuthorizationCodeUsed        = errors.New("authorization code already used")
 )
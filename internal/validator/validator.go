@@ -4,6 +4,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"regexp"
+	"strings"
 )
 
 const emailPattern = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
@@ -65,12 +66,79 @@ func CheckPhoneNumber(phoneNumber string) error {
 	return nil
 }
 
+// CheckPassword проверяет только формат, требования к новому паролю задает политика паролей
 func CheckPassword(password string) error {
 	if password == "" {
 		return status.Error(codes.InvalidArgument, "password is empty")
 	}
-	if (len(password) < 6) || (len(password) > 24) {
-		return status.Error(codes.InvalidArgument, "invalid password length")
+	// верхняя граница защищает от дорогого хеширования огромных строк, длину нового пароля задает политика
+	if len(password) > 256 {
+		return status.Error(codes.InvalidArgument, "password is too long")
+	}
+
+	return nil
+}
+
+// LoginKind тип идентификатора, по которому пользователь входит
+type LoginKind int
+
+const (
+	LoginUsername LoginKind = iota
+	LoginEmail
+	LoginPhone
+)
+
+// NormalizeEmail приводит email к нижнему регистру
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone приводит номер к E.164: убирает пробелы, скобки и дефисы, 00 в начале заменяет на +
+func NormalizePhone(phoneNumber string) string {
+	phone := strings.TrimSpace(phoneNumber)
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(phone)
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	return phone
+}
+
+// ClassifyLogin определяет, что передано при входе - username, email или телефон,
+// и возвращает нормализованное значение
+func ClassifyLogin(login string) (LoginKind, string, error) {
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return 0, "", status.Error(codes.InvalidArgument, "login is empty")
+	}
+
+	switch {
+	case strings.Contains(login, "@"):
+		email := NormalizeEmail(login)
+		if err := CheckEmail(email); err != nil {
+			return 0, "", err
+		}
+		return LoginEmail, email, nil
+	case strings.HasPrefix(login, "+") || strings.HasPrefix(login, "00"):
+		phone := NormalizePhone(login)
+		if err := CheckPhoneNumber(phone); err != nil {
+			return 0, "", err
+		}
+		return LoginPhone, phone, nil
+	}
+
+	if err := CheckUsername(login); err != nil {
+		return 0, "", err
+	}
+	return LoginUsername, login, nil
+}
+
+// CheckMFACode проверяет только формат, сам код проверяет сервис
+func CheckMFACode(code string) error {
+	if code == "" {
+		return status.Error(codes.InvalidArgument, "code is empty")
+	}
+	if len(code) > 32 {
+		return status.Error(codes.InvalidArgument, "invalid code length")
 	}
 
 	return nil
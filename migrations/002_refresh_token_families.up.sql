@@ -0,0 +1,11 @@
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id UUID,
+    ADD COLUMN used_at TIMESTAMPTZ,
+    ADD COLUMN revoked_at TIMESTAMPTZ;
+
+-- every token issued before families existed becomes its own family
+UPDATE refresh_tokens SET family_id = gen_random_uuid() WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
@@ -0,0 +1,3 @@
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
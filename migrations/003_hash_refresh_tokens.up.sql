@@ -0,0 +1,4 @@
+-- raw tokens can not be rehashed without the server secret, so existing sessions are invalidated
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
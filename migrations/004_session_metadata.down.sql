@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_active_user;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS session_started_at,
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS device_name;
@@ -0,0 +1,8 @@
+ALTER TABLE refresh_tokens
+    ADD COLUMN device_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip TEXT NOT NULL DEFAULT '',
+    ADD COLUMN session_started_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX idx_refresh_tokens_active_user ON refresh_tokens(user_id)
+    WHERE used_at IS NULL AND revoked_at IS NULL;
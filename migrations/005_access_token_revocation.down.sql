@@ -0,0 +1,7 @@
+ALTER TABLE users DROP COLUMN IF EXISTS blocked_at;
+
+DROP TABLE IF EXISTS revoked_access_tokens;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS access_expires_at,
+    DROP COLUMN IF EXISTS access_jti;
@@ -0,0 +1,15 @@
+-- access token issued together with each refresh token, needed to revoke it by session or user
+ALTER TABLE refresh_tokens
+    ADD COLUMN access_jti TEXT,
+    ADD COLUMN access_expires_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS revoked_access_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL, -- after this moment the token is invalid anyway
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_revoked_access_tokens_revoked_at ON revoked_access_tokens(revoked_at);
+CREATE INDEX idx_revoked_access_tokens_expires_at ON revoked_access_tokens(expires_at);
+
+ALTER TABLE users ADD COLUMN blocked_at TIMESTAMPTZ;
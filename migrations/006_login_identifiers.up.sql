@@ -0,0 +1,2 @@
+-- email is compared case-insensitively, new emails are stored lower-cased
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
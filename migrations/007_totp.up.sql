@@ -0,0 +1,19 @@
+-- TOTP secret is encrypted by the service (AES-GCM), the key is never stored in the database
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_encrypted BYTEA NOT NULL,
+    confirmed_at TIMESTAMPTZ, -- NULL until the user enters the first valid code
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- protects against replay of an accepted code
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- second login step, issued after a valid password when 2FA is enabled
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_mfa_challenges_expires_at ON mfa_challenges(expires_at);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id BYTEA PRIMARY KEY, -- credential id from the authenticator
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    public_key BYTEA NOT NULL, -- COSE_Key
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid BYTEA,
+    attestation_format TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webauthn_credentials_user ON webauthn_credentials(user_id);
+
+-- challenge of a registration or login ceremony, used once
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+    challenge BYTEA PRIMARY KEY,
+    kind TEXT NOT NULL, -- registration | login
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE, -- NULL for login with discoverable credential
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_webauthn_challenges_expires_at ON webauthn_challenges(expires_at);
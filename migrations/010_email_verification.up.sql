@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- only HMAC of the token is stored, like refresh tokens
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL, -- the address the link was sent to
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_email_verification_tokens_user ON email_verification_tokens(user_id);
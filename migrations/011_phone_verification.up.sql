@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMPTZ;
+
+-- one pending code per user, only HMAC of the code is stored
+CREATE TABLE IF NOT EXISTS phone_otps (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    phone_number TEXT NOT NULL, -- the number the code was sent to
+    code_hash TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+-- failed login counters: key is user:<id>, login:<unknown login> or ip:<address>
+CREATE TABLE IF NOT EXISTS login_failures (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX idx_login_failures_last_failure ON login_failures(last_failure_at);
@@ -0,0 +1,9 @@
+-- token buckets of the shared rate limiter, key is <method>|user:<id> or <method>|ip:<address>
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated ON rate_limit_buckets(updated_at);
@@ -0,0 +1,7 @@
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS client_id,
+    DROP COLUMN IF EXISTS scopes;
+
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_authorization_requests;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- /authorize requests waiting for the user to log in and consent, id is stored as HMAC like refresh tokens
+CREATE TABLE IF NOT EXISTS oauth_authorization_requests (
+    id_hash TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    state TEXT NOT NULL DEFAULT '',
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    family_id UUID -- session created by the exchange
+);
+
+CREATE INDEX idx_oauth_authorization_requests_expires_at ON oauth_authorization_requests(expires_at);
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+
+-- sessions of OAuth clients are refreshed only by the same client and keep the granted scopes
+ALTER TABLE refresh_tokens
+    ADD COLUMN client_id TEXT REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    ADD COLUMN scopes TEXT[];
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS oauth_client_access_tokens;
+
+ALTER TABLE oauth_clients
+    DROP COLUMN IF EXISTS secret_hash,
+    DROP COLUMN IF EXISTS grant_types;
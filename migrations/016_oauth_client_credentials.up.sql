@@ -0,0 +1,13 @@
+-- machine clients authenticate with a secret, it is stored as HMAC like refresh tokens
+ALTER TABLE oauth_clients
+    ADD COLUMN secret_hash TEXT,
+    ADD COLUMN grant_types TEXT[] NOT NULL DEFAULT '{authorization_code}';
+
+-- client_credentials tokens have no refresh token, they are tracked here to be revoked with the client
+CREATE TABLE IF NOT EXISTS oauth_client_access_tokens (
+    jti TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_oauth_client_access_tokens_client_id ON oauth_client_access_tokens(client_id);
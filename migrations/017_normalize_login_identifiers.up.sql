@@ -0,0 +1,30 @@
+-- emails and phone numbers saved before 006 may be stored as entered; normalize them the same way as
+-- validator.NormalizeEmail and validator.NormalizePhone so that login and uniqueness checks see one value
+CREATE FUNCTION pg_temp.normalize_phone(phone TEXT) RETURNS TEXT LANGUAGE sql IMMUTABLE AS $$
+    SELECT CASE WHEN p LIKE '00%' THEN '+' || substr(p, 3) ELSE p END
+    FROM (SELECT regexp_replace(btrim(phone), '[ ().-]', '', 'g') AS p) AS stripped
+$$;
+
+-- accounts that become duplicates can't be merged automatically, they have to be resolved by hand first
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(value, ', ') INTO duplicates FROM (
+        SELECT lower(btrim(email)) AS value FROM users GROUP BY 1 HAVING count(*) > 1
+        UNION ALL
+        SELECT pg_temp.normalize_phone(phone_number) FROM users GROUP BY 1 HAVING count(*) > 1
+    ) AS d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users share email or phone number after normalization: %', duplicates;
+    END IF;
+END
+$$;
+
+UPDATE users SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+UPDATE users SET phone_number = pg_temp.normalize_phone(phone_number) WHERE phone_number <> pg_temp.normalize_phone(phone_number);
+
+-- email is unique case-insensitively, the index replaces both the plain constraint and idx_users_email_lower
+DROP INDEX IF EXISTS idx_users_email_lower;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX users_email_lower_key ON users(lower(email));